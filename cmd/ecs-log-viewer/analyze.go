@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/patterns"
+)
+
+// runAnalyzePatterns fetches the log messages of the selected container and prints
+// a report of message templates sorted by frequency
+func runAnalyzePatterns(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+
+	targets, err := setupAWSTargets(ctx, runOption)
+	if err != nil {
+		return err
+	}
+
+	runOption, _, err = selectContainer(ctx, targets, runOption)
+	if err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-runOption.duration)
+
+	log.Printf("Time range: %s to %s\n", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+
+	results, err := fetchLogs(ctx, targets, runOption, []string{"@timestamp", "@message"}, startTime, endTime)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		log.Println("No logs found in the specified time range")
+		return nil
+	}
+
+	clusters := patterns.ClusterEvents(results)
+	log.Printf("Found %d patterns in %d events\n", len(clusters), len(results))
+
+	if top := c.Int("top"); top > 0 && len(clusters) > top {
+		clusters = clusters[:top]
+	}
+
+	redactor, err := newRedactor(runOption)
+	if err != nil {
+		return err
+	}
+	if redactor != nil {
+		patterns.RedactClusters(clusters, redactor.Redact)
+	}
+
+	writer, err := openOutput(runOption.output)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(writer)
+
+	if err := patterns.WriteClusters(writer, clusters); err != nil {
+		return fmt.Errorf("failed to write pattern report: %v", err)
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -15,34 +18,247 @@ import (
 	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/awsauth"
 	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	appConfig "github.com/bonyuta0204/ecs-log-viewer/pkg/config"
 	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/histogram"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/loglevel"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/outputfile"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/redact"
 	"github.com/bonyuta0204/ecs-log-viewer/pkg/selector"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/sink"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/sqliteexport"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/tui"
 )
 
 // AppOption contains configuration options for the ECS log viewer application
 type AppOption struct {
-	profile   string
-	region    string
-	duration  time.Duration
-	taskdef   string
-	container string
-	filter    string
-	web       bool
-	fields    []string
-	output    string
-	format    string
+	profiles        []string
+	regions         []string
+	roleArn         string
+	externalID      string
+	mfaSerial       string
+	sessionDuration time.Duration
+	duration        time.Duration
+	taskdef         string
+	container       string
+	filter          string
+	web             bool
+	fields          []string
+	output          string
+	format          string
+	multilineStart  string
+	histogram       bool
+	histogramLevels bool
+	histogramBins   int
+	cluster         string
+	service         string
+	serviceEvents   bool
+	tui             bool
+	redact          bool
+	configPath      string
+	template        string
+	templateFile    string
+	noTruncate      bool
+	splitSize       string
+	splitEvents     int
+	splitHourly     bool
+	sqliteTable     string
+	sinks           []string
+	sinkBatchSize   int
+	pickFields      bool
+	sort            string
+	limit           int
+	head            int
+	tail            int
+	timeFormat      string
+	timeZone        string
+	afterContext    int
+	beforeContext   int
+	context         int
+	allServices     bool
 }
 
 func (o *AppOption) validate() error {
+	if o.roleArn == "" && (o.externalID != "" || o.mfaSerial != "") {
+		return fmt.Errorf("--external-id and --mfa-serial can only be used together with --role-arn")
+	}
+	if o.web && (len(o.profiles) > 1 || len(o.regions) > 1) {
+		return fmt.Errorf("--web can only be used with a single profile and region")
+	}
+	if o.serviceEvents && (len(o.profiles) > 1 || len(o.regions) > 1) {
+		return fmt.Errorf("--service-events can only be used with a single profile and region")
+	}
+
+	if o.allServices {
+		if len(o.profiles) > 1 || len(o.regions) > 1 {
+			return fmt.Errorf("--all-services can only be used with a single profile and region")
+		}
+		if o.taskdef != "" || o.service != "" || o.serviceEvents || o.web || o.tui || o.pickFields || o.histogram {
+			return fmt.Errorf("--all-services cannot be used with --taskdef, --service, --service-events, --web, --tui, --pick-fields or --histogram")
+		}
+	}
+	if o.tui && (o.web || o.output != "" || o.histogram || len(o.sinks) > 0) {
+		return fmt.Errorf("--tui cannot be used with --web, --output, --histogram or --sink")
+	}
+	if _, err := o.newSinks(); err != nil {
+		return err
+	}
+
+	if o.multilineStart != "" {
+		if _, err := cloudwatchclient.NewMultilineMatcher(o.multilineStart); err != nil {
+			return err
+		}
+	}
+
+	if _, err := o.resultOrder(); err != nil {
+		return err
+	}
+
+	if _, err := cloudwatchclient.ParseTimeFormat(o.timeFormat, o.timeZone); err != nil {
+		return err
+	}
+
+	contextOption, err := o.contextOption()
+	if err != nil {
+		return err
+	}
+	if contextOption.Enabled() {
+		if len(o.profiles) > 1 || len(o.regions) > 1 {
+			return fmt.Errorf("context lines can only be fetched with a single profile and region")
+		}
+		if o.tui || o.serviceEvents || o.allServices {
+			return fmt.Errorf("--after-context, --before-context and --context cannot be used with --tui, --service-events or --all-services")
+		}
+	}
+
+	split, err := o.splitOption()
+	if err != nil {
+		return err
+	}
+	if split.Enabled() {
+		if o.output == "" {
+			return fmt.Errorf("--split-size, --split-events and --split-hourly require --output")
+		}
+		if o.splitHourly && !slices.Contains(o.fields, "@timestamp") {
+			return fmt.Errorf("--split-hourly requires @timestamp in --fields")
+		}
+	}
+	return nil
+}
+
+// resultOrder returns which events are fetched and in which order.
+// --head and --tail list the oldest or newest events in ascending order unless --sort is given;
+// --limit keeps the first events in --sort order, the newest first by default.
+func (o *AppOption) resultOrder() (cloudwatchclient.ResultOrder, error) {
+	sort, err := cloudwatchclient.ParseSortOrder(o.sort)
+	if err != nil {
+		return cloudwatchclient.ResultOrder{}, err
+	}
+
+	count := 0
+	for _, n := range []int{o.limit, o.head, o.tail} {
+		if n < 0 || n > cloudwatchclient.MaxQueryLimit {
+			return cloudwatchclient.ResultOrder{}, fmt.Errorf("--limit, --head and --tail must be between 1 and %d", cloudwatchclient.MaxQueryLimit)
+		}
+		if n > 0 {
+			count++
+		}
+	}
+	if count > 1 {
+		return cloudwatchclient.ResultOrder{}, fmt.Errorf("only one of --limit, --head and --tail can be used")
+	}
+
+	switch {
+	case o.head > 0:
+		return cloudwatchclient.ResultOrder{Sort: orDefault(sort, cloudwatchclient.SortAsc), Limit: o.head}, nil
+	case o.tail > 0:
+		return cloudwatchclient.ResultOrder{Sort: orDefault(sort, cloudwatchclient.SortAsc), Limit: o.tail, Newest: true}, nil
+	case o.limit > 0:
+		sort = orDefault(sort, cloudwatchclient.SortDesc)
+		return cloudwatchclient.ResultOrder{Sort: sort, Limit: o.limit, Newest: sort == cloudwatchclient.SortDesc}, nil
+	default:
+		return cloudwatchclient.ResultOrder{Sort: sort}, nil
+	}
+}
+
+func orDefault(sort, def cloudwatchclient.SortOrder) cloudwatchclient.SortOrder {
+	if sort == "" {
+		return def
+	}
+	return sort
+}
+
+// contextOption returns the number of events shown around each match. --context sets both
+// numbers unless they are given separately, like grep -C.
+func (o *AppOption) contextOption() (cloudwatchclient.ContextOption, error) {
+	if o.afterContext < 0 || o.beforeContext < 0 || o.context < 0 {
+		return cloudwatchclient.ContextOption{}, fmt.Errorf("--after-context, --before-context and --context must not be negative")
+	}
+	opt := cloudwatchclient.ContextOption{Before: o.beforeContext, After: o.afterContext}
+	if opt.Before == 0 {
+		opt.Before = o.context
+	}
+	if opt.After == 0 {
+		opt.After = o.context
+	}
+	return opt, nil
+}
+
+// splitOption returns how the output file is split into multiple files
+func (o *AppOption) splitOption() (outputfile.SplitOption, error) {
+	var opt outputfile.SplitOption
+	count := 0
+	if o.splitSize != "" {
+		size, err := outputfile.ParseSize(o.splitSize)
+		if err != nil {
+			return opt, err
+		}
+		opt.MaxBytes = size
+		count++
+	}
+	if o.splitEvents > 0 {
+		opt.MaxEvents = o.splitEvents
+		count++
+	}
+	if o.splitHourly {
+		opt.Hourly = true
+		count++
+	}
+	if count > 1 {
+		return opt, fmt.Errorf("only one of --split-size, --split-events and --split-hourly can be used")
+	}
+	return opt, nil
+}
+
+// validateFormat checks that the output format can render the selected fields
+func (o *AppOption) validateFormat() error {
 	switch o.format {
 	case "simple":
 		if len(o.fields) != 1 {
 			return fmt.Errorf("simple format can only be used when exactly one field is selected")
 		}
+		if len(o.profiles) > 1 || len(o.regions) > 1 {
+			return fmt.Errorf("simple format cannot show the region and account of each event; use another format with multiple profiles or regions")
+		}
 
-	case "csv", "json":
+	case "csv", "json", "jsonl", "table":
+
+	case "sqlite":
+		if o.output == "" {
+			return fmt.Errorf("sqlite format requires --output with the database file")
+		}
+		if split, _ := o.splitOption(); split.Enabled() {
+			return fmt.Errorf("sqlite format cannot be split into multiple files")
+		}
+
+	case "template":
+		if (o.template == "") == (o.templateFile == "") {
+			return fmt.Errorf("template format requires exactly one of --template or --template-file")
+		}
 
 	default:
 		return fmt.Errorf("invalid format: %s", o.format)
@@ -51,27 +267,76 @@ func (o *AppOption) validate() error {
 }
 
 func newAppOption(c *cli.Context) AppOption {
+	fields := c.StringSlice("fields")
+	if c.Bool("all-services") && !c.IsSet("fields") {
+		// a merged view of several services is only readable with the time of each event
+		fields = []string{"@timestamp", "@message"}
+	}
+	if (c.String("format") == "template" || c.String("format") == "sqlite") && !c.IsSet("fields") {
+		// templates usually render more than the message, and the database derives
+		// the task and container columns from the stream, so make the common fields available
+		fields = []string{"@timestamp", "@logStream", "@message"}
+	}
+	if len(c.StringSlice("sink")) > 0 && !c.IsSet("fields") {
+		// sinks index the events by time
+		fields = []string{"@timestamp", "@message"}
+	}
+
 	return AppOption{
-		profile:   c.String("profile"),
-		region:    c.String("region"),
-		duration:  c.Duration("duration"),
-		taskdef:   c.String("taskdef"),
-		container: c.String("container"),
-		filter:    c.String("filter"),
-		web:       c.Bool("web"),
-		fields:    c.StringSlice("fields"),
-		output:    c.String("output"),
-		format:    c.String("format"),
+		profiles:        c.StringSlice("profile"),
+		regions:         c.StringSlice("region"),
+		roleArn:         c.String("role-arn"),
+		externalID:      c.String("external-id"),
+		mfaSerial:       c.String("mfa-serial"),
+		sessionDuration: c.Duration("session-duration"),
+		duration:        c.Duration("duration"),
+		taskdef:         c.String("taskdef"),
+		container:       c.String("container"),
+		filter:          c.String("filter"),
+		web:             c.Bool("web"),
+		fields:          fields,
+		output:          c.String("output"),
+		format:          c.String("format"),
+		multilineStart:  c.String("multiline-start"),
+		histogram:       c.Bool("histogram") || c.Bool("histogram-levels"),
+		histogramLevels: c.Bool("histogram-levels"),
+		histogramBins:   c.Int("histogram-buckets"),
+		cluster:         c.String("cluster"),
+		service:         c.String("service"),
+		serviceEvents:   c.Bool("service-events"),
+		tui:             c.Bool("tui"),
+		redact:          c.Bool("redact"),
+		configPath:      c.String("config"),
+		template:        c.String("template"),
+		templateFile:    c.String("template-file"),
+		noTruncate:      c.Bool("no-truncate"),
+		splitSize:       c.String("split-size"),
+		splitEvents:     c.Int("split-events"),
+		splitHourly:     c.Bool("split-hourly"),
+		sqliteTable:     c.String("sqlite-table"),
+		sinks:           c.StringSlice("sink"),
+		sinkBatchSize:   c.Int("sink-batch-size"),
+		pickFields:      c.Bool("pick-fields"),
+		sort:            c.String("sort"),
+		limit:           c.Int("limit"),
+		head:            c.Int("head"),
+		tail:            c.Int("tail"),
+		timeFormat:      c.String("time-format"),
+		timeZone:        c.String("tz"),
+		afterContext:    c.Int("after-context"),
+		beforeContext:   c.Int("before-context"),
+		context:         c.Int("context"),
+		allServices:     c.Bool("all-services"),
 	}
 }
 
-func setupAWSConfig(ctx context.Context, runOption AppOption) (aws.Config, error) {
+func setupAWSConfig(ctx context.Context, runOption AppOption, profile, region string) (aws.Config, error) {
 	opts := []func(*config.LoadOptions) error{}
 
-	if profile := runOption.profile; profile != "" {
+	if profile != "" {
 		opts = append(opts, config.WithSharedConfigProfile(profile))
 	}
-	if region := runOption.region; region != "" {
+	if region != "" {
 		opts = append(opts, config.WithRegion(region))
 	}
 
@@ -79,6 +344,22 @@ func setupAWSConfig(ctx context.Context, runOption AppOption) (aws.Config, error
 	if err != nil {
 		return aws.Config{}, fmt.Errorf("unable to load AWS SDK config: %v", err)
 	}
+
+	if runOption.roleArn != "" {
+		cacheDir, err := awsauth.DefaultCacheDir()
+		if err != nil {
+			log.Printf("Warning: assumed role session will not be cached: %v\n", err)
+		}
+		cfg = awsauth.WithAssumeRole(cfg, awsauth.AssumeRoleOption{
+			RoleARN:    runOption.roleArn,
+			ExternalID: runOption.externalID,
+			MFASerial:  runOption.mfaSerial,
+			Duration:   runOption.sessionDuration,
+			Profile:    profile,
+		}, cacheDir, func() (string, error) {
+			return selector.InputText(fmt.Sprintf("Enter MFA code for %s > ", runOption.mfaSerial))
+		})
+	}
 	return cfg, nil
 }
 
@@ -133,6 +414,9 @@ func selectTaskAndContainer(ecsClient *ecsclient.EcsClient, appOption AppOption)
 }
 
 func getLogConfiguration(containerDef *ecsTypes.ContainerDefinition) (string, string, error) {
+	if containerDef.LogConfiguration == nil {
+		return "", "", fmt.Errorf("log configuration not set")
+	}
 	logOpts := containerDef.LogConfiguration.Options
 	logGroup, ok := logOpts["awslogs-group"]
 	if !ok {
@@ -145,29 +429,147 @@ func getLogConfiguration(containerDef *ecsTypes.ContainerDefinition) (string, st
 	return logGroup, logStreamPrefix + "/" + *containerDef.Name, nil
 }
 
-func writeResults(results [][]cwTypes.ResultField, output string, format string) error {
-	var writer io.Writer
-	var file *os.File
+// nopWriteCloser is used for stdout, which must not be closed
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
 
+// openOutput opens the output file, or stdout when output is empty.
+// Files ending with .gz or .zst are compressed.
+func openOutput(output string) (io.WriteCloser, error) {
 	if output == "" {
-		writer = os.Stdout
-	} else {
-		var err error
-		file, err = os.Create(output)
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	file, err := outputfile.Create(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %v", err)
+	}
+	return file, nil
+}
+
+// closeOutput closes the output opened with openOutput, logging any error
+func closeOutput(writer io.WriteCloser) {
+	if err := writer.Close(); err != nil {
+		log.Printf("Warning: failed to close output file: %v\n", err)
+	}
+}
+
+// newRedactor returns the redactor configured by --redact and the config file, or nil when redaction is disabled
+func newRedactor(runOption AppOption) (*redact.Redactor, error) {
+	cfg, err := appConfig.Load(runOption.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if !runOption.redact && !cfg.Redact.Enabled {
+		return nil, nil
+	}
+
+	rules := redact.DefaultRules()
+	for _, r := range cfg.Redact.Rules {
+		rule, err := redact.NewRule(r.Name, r.Pattern)
 		if err != nil {
-			return fmt.Errorf("failed to create output file: %v", err)
+			return nil, err
 		}
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Printf("Warning: failed to close output file: %v\n", err)
+		rules = append(rules, rule)
+	}
+	return redact.New(rules), nil
+}
+
+// newWriteOptions builds the options of the output format
+func newWriteOptions(runOption AppOption) (cloudwatchclient.WriteOptions, error) {
+	opts := cloudwatchclient.WriteOptions{WriteHeader: true}
+
+	timeFormat, err := cloudwatchclient.ParseTimeFormat(runOption.timeFormat, runOption.timeZone)
+	if err != nil {
+		return opts, err
+	}
+	opts.Time = timeFormat
+
+	if runOption.format == "template" {
+		text := runOption.template
+		if runOption.templateFile != "" {
+			data, err := os.ReadFile(runOption.templateFile)
+			if err != nil {
+				return opts, fmt.Errorf("failed to read template file: %v", err)
 			}
-		}()
-		writer = file
+			text = string(data)
+		}
+		tmpl, err := cloudwatchclient.NewTemplate(text, timeFormat)
+		if err != nil {
+			return opts, err
+		}
+		opts.Template = tmpl
 	}
 
-	outputFormat := cloudwatchclient.OutputFormat(format)
-	if err := cloudwatchclient.WriteLogEvents(writer, results, outputFormat, true); err != nil {
-		return fmt.Errorf("failed to write results in %s format: %v", format, err)
+	if contextOption, err := runOption.contextOption(); err == nil && contextOption.Enabled() {
+		opts.GroupField = cloudwatchclient.ContextGroupField
+	}
+
+	// fit the table to the terminal only when it is printed there
+	if runOption.format == "table" && !runOption.noTruncate && runOption.output == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+		opts.Width = terminalWidth(os.Stdout)
+	}
+
+	return opts, nil
+}
+
+func writeResults(results [][]cwTypes.ResultField, runOption AppOption) error {
+	output, format := runOption.output, runOption.format
+
+	redactor, err := newRedactor(runOption)
+	if err != nil {
+		return err
+	}
+	if redactor != nil {
+		redactor.RedactEvents(results)
+	}
+
+	if len(runOption.sinks) > 0 {
+		if err := writeSinks(results, runOption); err != nil {
+			return err
+		}
+		// the sinks replace stdout, but a file is still written when requested
+		if output == "" {
+			return nil
+		}
+	}
+
+	if format == "sqlite" {
+		inserted, err := sqliteexport.Write(output, runOption.sqliteTable, results)
+		if err != nil {
+			return fmt.Errorf("failed to write results to SQLite database: %v", err)
+		}
+		log.Printf("Inserted %d of %d events into table %s of SQLite database: %s\n", inserted, len(results), runOption.sqliteTable, output)
+		return nil
+	}
+
+	writeOptions, err := newWriteOptions(runOption)
+	if err != nil {
+		return err
+	}
+
+	split, err := runOption.splitOption()
+	if err != nil {
+		return err
+	}
+	if split.Enabled() {
+		parts, err := outputfile.Split(output, results, split)
+		if err != nil {
+			return err
+		}
+		for _, part := range parts {
+			if err := writeResultsTo(part.Path, part.Events, format, writeOptions); err != nil {
+				return err
+			}
+			log.Printf("Wrote %d events in %s format to file: %s\n", len(part.Events), format, part.Path)
+		}
+		return nil
+	}
+
+	if err := writeResultsTo(output, results, format, writeOptions); err != nil {
+		return err
 	}
 
 	if output != "" {
@@ -177,51 +579,232 @@ func writeResults(results [][]cwTypes.ResultField, output string, format string)
 	return nil
 }
 
+// sinkMaxRetries is how often a failed request to a sink is retried
+const sinkMaxRetries = 3
+
+// newSinks creates the sinks given with --sink
+func (o *AppOption) newSinks() ([]sink.Sink, error) {
+	opt := sink.Options{
+		BatchSize:  o.sinkBatchSize,
+		MaxRetries: sinkMaxRetries,
+		Labels:     map[string]string{},
+	}
+	if o.taskdef != "" {
+		opt.Labels["task_definition"] = o.taskdef
+	}
+	if o.container != "" {
+		opt.Labels["container"] = o.container
+	}
+
+	var sinks []sink.Sink
+	for _, spec := range o.sinks {
+		s, err := sink.New(spec, opt)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// writeSinks forwards the results to every sink given with --sink
+func writeSinks(results [][]cwTypes.ResultField, runOption AppOption) error {
+	sinks, err := runOption.newSinks()
+	if err != nil {
+		return err
+	}
+	for i, s := range sinks {
+		if err := s.Write(context.Background(), results); err != nil {
+			return fmt.Errorf("failed to write results to sink %s: %v", sinkName(runOption.sinks[i]), err)
+		}
+		log.Printf("Sent %d events to sink: %s\n", len(results), sinkName(runOption.sinks[i]))
+	}
+	return nil
+}
+
+// sinkName returns the sink spec with any password in the URL masked
+func sinkName(spec string) string {
+	kind, rawURL, _ := strings.Cut(spec, "=")
+	if u, err := url.Parse(rawURL); err == nil {
+		rawURL = u.Redacted()
+	}
+	return kind + "=" + rawURL
+}
+
+// writeResultsTo writes the results to the output file, or stdout when output is empty
+func writeResultsTo(output string, results [][]cwTypes.ResultField, format string, writeOptions cloudwatchclient.WriteOptions) error {
+	writer, err := openOutput(output)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(writer)
+
+	outputFormat := cloudwatchclient.OutputFormat(format)
+	if err := cloudwatchclient.WriteLogEvents(writer, results, outputFormat, writeOptions); err != nil {
+		return fmt.Errorf("failed to write results in %s format: %v", format, err)
+	}
+	return nil
+}
+
+// selectContainer selects the task definition family and container with the first target.
+// The returned option has the selection pinned so that the other targets, and any later
+// lookups, are resolved with the same task definition family and container names.
+func selectContainer(ctx context.Context, targets []awsTarget, runOption AppOption) (AppOption, *ecsTypes.ContainerDefinition, error) {
+	ecsClient := ecsclient.NewEcsClient(ctx, &targets[0].cfg)
+	taskDef, containerDef, err := selectTaskAndContainer(ecsClient, runOption)
+	if err != nil {
+		return runOption, nil, err
+	}
+	runOption.taskdef = *taskDef.Family
+	runOption.container = *containerDef.Name
+	return runOption, containerDef, nil
+}
+
+// fetchLogs queries the given fields of the selected container in every target.
+// Fields that are only needed for post-processing are removed from the results.
+func fetchLogs(ctx context.Context, targets []awsTarget, runOption AppOption, fields []string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	queryFields := fields
+	var hiddenFields []string
+	var process func(events [][]cwTypes.ResultField) [][]cwTypes.ResultField
+
+	order, err := runOption.resultOrder()
+	if err != nil {
+		return nil, err
+	}
+	if order.Enabled() || len(targets) > 1 {
+		// sorting, limiting and merging the results of several targets need the timestamps
+		queryFields, hiddenFields = cloudwatchclient.IncludeFields(queryFields, "@timestamp")
+	}
+
+	if runOption.multilineStart != "" {
+		isStart, err := cloudwatchclient.NewMultilineMatcher(runOption.multilineStart)
+		if err != nil {
+			return nil, err
+		}
+		// Stitching needs to know the order and stream of each line even if they are not displayed.
+		// The ingestion time orders lines logged in the same millisecond.
+		var added []string
+		queryFields, added = cloudwatchclient.IncludeFields(queryFields, "@timestamp", "@ingestionTime", "@logStream", "@message")
+		hiddenFields = append(hiddenFields, added...)
+		process = func(events [][]cwTypes.ResultField) [][]cwTypes.ResultField {
+			return cloudwatchclient.StitchMultiline(events, isStart)
+		}
+	}
+
+	results, err := queryTargets(ctx, targets, runOption, logQuery{
+		build: func(logStreamPrefix string) string {
+			return cloudwatchclient.BuildCloudWatchQuery(logStreamPrefix, queryFields, runOption.filter) + order.QueryCommands()
+		},
+		process: process,
+	}, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	results = order.Apply(results)
+	cloudwatchclient.DropFields(results, hiddenFields...)
+	return results, nil
+}
+
 func runApp(c *cli.Context) error {
 	ctx := context.Background()
 	runOption := newAppOption(c)
 	log.SetFlags(0)
 
-	err := runOption.validate()
-	if err != nil {
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+	if err := runOption.validateFormat(); err != nil {
 		return err
 	}
 
-	cfg, err := setupAWSConfig(ctx, runOption)
+	targets, err := setupAWSTargets(ctx, runOption)
 	if err != nil {
 		return err
 	}
 
-	ecsClient := ecsclient.NewEcsClient(ctx, &cfg)
-	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &cfg)
+	if runOption.allServices {
+		return runAllServices(ctx, targets[0], runOption)
+	}
 
-	_, containerDef, err := selectTaskAndContainer(ecsClient, runOption)
+	runOption, containerDef, err := selectContainer(ctx, targets, runOption)
 	if err != nil {
 		return err
 	}
 
-	logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
-	if err != nil {
-		return err
+	if runOption.pickFields {
+		runOption.fields, err = pickFields(ctx, targets[0], runOption, containerDef)
+		if err != nil {
+			return err
+		}
+		if err := runOption.validateFormat(); err != nil {
+			return err
+		}
 	}
 
 	endTime := time.Now()
 	startTime := endTime.Add(-runOption.duration)
 
-	log.Printf("Fetching logs from log group: %s, stream prefix: %s\n", logGroup, logStreamPrefix)
 	log.Printf("Time range: %s to %s\n", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
 
-	query := cloudwatchclient.BuildCloudWatchQuery(logStreamPrefix, runOption.fields, runOption.filter)
-
 	if runOption.web {
-		consoleURL := cloudwatchclient.BuildConsoleURL(cfg.Region, logGroup, query, runOption.duration)
+		logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
+		if err != nil {
+			return err
+		}
+		order, err := runOption.resultOrder()
+		if err != nil {
+			return err
+		}
+		query := cloudwatchclient.BuildCloudWatchQuery(logStreamPrefix, runOption.fields, runOption.filter) + order.QueryCommands()
+		consoleURL := cloudwatchclient.BuildConsoleURL(targets[0].cfg.Region, logGroup, query, runOption.duration)
 		log.Printf("Opening AWS Console URL: %s\n", consoleURL)
 		return openBrowser(consoleURL)
 	}
 
-	results, err := logsClient.QueryLogs(logGroup, query, startTime, endTime)
+	var service *ecsclient.Service
+	if runOption.serviceEvents {
+		ecsClient := ecsclient.NewEcsClient(ctx, &targets[0].cfg)
+		cluster, err := selectCluster(ecsClient, runOption)
+		if err != nil {
+			return err
+		}
+		service, err = selectService(ecsClient, cluster, runOption, runOption.taskdef)
+		if err != nil {
+			return err
+		}
+		log.Printf("Including events of service: %s\n", aws.ToString(service.ServiceName))
+	}
+
+	results, err := collectResults(ctx, targets, runOption, service, startTime, endTime)
 	if err != nil {
-		return fmt.Errorf("failed to query logs: %v", err)
+		return err
+	}
+
+	if runOption.tui {
+		redactor, err := newRedactor(runOption)
+		if err != nil {
+			return err
+		}
+		if redactor != nil {
+			redactor.RedactEvents(results)
+		}
+
+		// progress messages of re-run queries would corrupt the screen
+		log.SetOutput(io.Discard)
+		return tui.Run(results, tui.Option{
+			Filter:   runOption.filter,
+			Duration: runOption.duration,
+			Query: func(filter string, duration time.Duration) ([][]cwTypes.ResultField, error) {
+				queryOption := runOption
+				queryOption.filter = filter
+				endTime := time.Now()
+				results, err := collectResults(ctx, targets, queryOption, service, endTime.Add(-duration), endTime)
+				if err == nil && redactor != nil {
+					redactor.RedactEvents(results)
+				}
+				return results, err
+			},
+		})
 	}
 
 	if len(results) == 0 {
@@ -229,7 +812,113 @@ func runApp(c *cli.Context) error {
 		return nil
 	}
 
-	return writeResults(results, runOption.output, runOption.format)
+	return writeResults(results, runOption)
+}
+
+// collectResults fetches the logs of the selected container and merges the events of the service, if any.
+// When requested, the histogram of the fetched logs is drawn to stderr.
+func collectResults(ctx context.Context, targets []awsTarget, runOption AppOption, service *ecsclient.Service, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	queryFields := runOption.fields
+	var hiddenFields []string
+	if runOption.histogram {
+		queryFields, hiddenFields = cloudwatchclient.IncludeFields(queryFields, "@timestamp", "@message")
+	}
+	if service != nil {
+		// service events are interleaved with the logs by timestamp
+		var added []string
+		queryFields, added = cloudwatchclient.IncludeFields(queryFields, "@timestamp")
+		hiddenFields = append(hiddenFields, added...)
+	}
+	contextOption, err := runOption.contextOption()
+	if err != nil {
+		return nil, err
+	}
+	if contextOption.Enabled() {
+		// context lines are looked up by the stream, time and message of each match
+		var added []string
+		queryFields, added = cloudwatchclient.IncludeFields(queryFields, "@timestamp", "@logStream", "@message")
+		hiddenFields = append(hiddenFields, added...)
+	}
+
+	results, err := fetchLogs(ctx, targets, runOption, queryFields, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if runOption.histogram && len(results) > 0 {
+		if err := writeHistogram(os.Stderr, results, startTime, endTime, runOption); err != nil {
+			return nil, err
+		}
+	}
+
+	if contextOption.Enabled() && len(results) > 0 {
+		results, err = fetchContext(ctx, targets[0], runOption, results, contextOption)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if service != nil {
+		results = append(results, serviceEventRows(service, queryFields, startTime, endTime)...)
+		cloudwatchclient.SortByTimestamp(results)
+		if order, err := runOption.resultOrder(); err == nil {
+			results = order.Apply(results)
+		}
+	}
+	cloudwatchclient.DropFields(results, hiddenFields...)
+	return results, nil
+}
+
+// fetchContext adds the events logged around each match in the same log stream
+func fetchContext(ctx context.Context, target awsTarget, runOption AppOption, matches [][]cwTypes.ResultField, contextOption cloudwatchclient.ContextOption) ([][]cwTypes.ResultField, error) {
+	ecsClient := ecsclient.NewEcsClient(ctx, &target.cfg)
+	_, containerDef, err := selectTaskAndContainer(ecsClient, runOption)
+	if err != nil {
+		return nil, err
+	}
+	logGroup, _, err := getLogConfiguration(containerDef)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching context lines of %d matches\n", len(matches))
+	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &target.cfg)
+	return logsClient.WithContext(logGroup, matches, contextOption)
+}
+
+// writeHistogram draws the distribution of the events over the time range
+func writeHistogram(w *os.File, results [][]cwTypes.ResultField, startTime, endTime time.Time, runOption AppOption) error {
+	h := histogram.New(startTime, endTime, runOption.histogramBins)
+	for _, event := range results {
+		value, _ := cloudwatchclient.FieldValue(event, "@timestamp")
+		timestamp, err := cloudwatchclient.ParseTimestamp(value)
+		if err != nil {
+			continue
+		}
+		message, _ := cloudwatchclient.FieldValue(event, "@message")
+		h.Add(timestamp, loglevel.Detect(message))
+	}
+
+	// leave room for the time label, counts and level summary
+	width := terminalWidth(w) - 50
+	if width < 10 {
+		width = 10
+	}
+
+	if err := h.Render(w, width, runOption.histogramLevels); err != nil {
+		return fmt.Errorf("failed to render histogram: %v", err)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// terminalWidth returns the width of the terminal attached to f, or 80 if it is not a terminal
+func terminalWidth(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 {
+		return 80
+	}
+	return width
 }
 
 func openBrowser(url string) error {
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+)
+
+// runAllServices queries the logs of every container of every service in the cluster at once
+func runAllServices(ctx context.Context, target awsTarget, runOption AppOption) error {
+	ecsClient := ecsclient.NewEcsClient(ctx, &target.cfg)
+	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &target.cfg)
+
+	cluster, err := selectCluster(ecsClient, runOption)
+	if err != nil {
+		return err
+	}
+	sources, err := clusterSources(ecsClient, cluster, runOption.container)
+	if err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-runOption.duration)
+	log.Printf("Time range: %s to %s\n", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+
+	results, err := queryLogSources(logsClient, sources, runOption, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		log.Println("No logs found in the specified time range")
+		return nil
+	}
+	return writeResults(results, runOption)
+}
+
+// clusterSources resolves the current task definition of every service in the cluster and the log
+// configuration of its containers. When container is given, only containers of that name are included.
+func clusterSources(ecsClient *ecsclient.EcsClient, cluster, container string) ([]logSource, error) {
+	serviceNames, err := ecsClient.ListServices(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %v", err)
+	}
+	if len(serviceNames) == 0 {
+		return nil, fmt.Errorf("no services found in cluster %s", cluster)
+	}
+	services, err := ecsClient.DescribeServices(cluster, serviceNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe services: %v", err)
+	}
+
+	// services may run the same task definition
+	taskDefs := make(map[string]*ecsTypes.TaskDefinition)
+	var sources []logSource
+	for _, service := range services {
+		arn := aws.ToString(service.TaskDefinition)
+		taskDef, ok := taskDefs[arn]
+		if !ok {
+			taskDef, err = ecsClient.DescribeTaskDefinition(arn)
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe task definition of service %s: %v", service.Label(), err)
+			}
+			taskDefs[arn] = taskDef
+		}
+		sources = append(sources, containerSources(service.Label(), taskDef, container)...)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no containers with CloudWatch Logs configuration found in cluster %s", cluster)
+	}
+	log.Printf("Fetching logs of %d containers of %d services in cluster: %s\n", len(sources), len(services), ecsclient.ResourceName(cluster))
+	return sources, nil
+}
+
+// queryLogSources queries the logs of the sources with as few queries as possible, each searching
+// up to MaxQueryLogGroups log groups concurrently. The results are merged in timestamp order and
+// labelled with service and container columns.
+func queryLogSources(logsClient *cloudwatchclient.CloudWatchClient, sources []logSource, runOption AppOption, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	order, err := runOption.resultOrder()
+	if err != nil {
+		return nil, err
+	}
+	// the log group and stream of each event tell which container logged it
+	queryFields, hiddenFields := cloudwatchclient.IncludeFields(runOption.fields, "@timestamp", "@log", "@logStream")
+
+	batches := logGroupBatches(sources)
+	results := make([][][]cwTypes.ResultField, len(batches))
+	errs := make([]error, len(batches))
+
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []logSource) {
+			defer wg.Done()
+			logGroups := batchLogGroups(batch)
+			var prefixes []string
+			for _, source := range batch {
+				prefixes = appendUnique(prefixes, source.logStreamPrefix)
+			}
+			log.Printf("Fetching logs from %d log groups: %s\n", len(logGroups), strings.Join(logGroups, ", "))
+			query := cloudwatchclient.BuildMultiStreamQuery(prefixes, queryFields, runOption.filter) + order.QueryCommands()
+			results[i], errs[i] = logsClient.QueryLogGroups(logGroups, query, startTime, endTime)
+		}(i, batch)
+	}
+	wg.Wait()
+
+	var merged [][]cwTypes.ResultField
+	for i := range batches {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to query logs: %v", errs[i])
+		}
+		// each query returns at most MaxQueryLimit events of all of its log groups
+		warnQueryLimit(results[i], fmt.Sprintf("%d log groups", len(batchLogGroups(batches[i]))))
+		merged = append(merged, results[i]...)
+	}
+
+	// a stream prefix may also match streams of other containers in another of the log groups
+	labelled := merged[:0]
+	for _, event := range merged {
+		source, ok := sourceOf(sources, event)
+		if !ok {
+			continue
+		}
+		labelled = append(labelled, append(event,
+			cwTypes.ResultField{Field: aws.String("service"), Value: aws.String(source.service)},
+			cwTypes.ResultField{Field: aws.String("container"), Value: aws.String(source.container)},
+		))
+	}
+	merged = labelled
+
+	cloudwatchclient.SortByTimestamp(merged)
+	merged = order.Apply(merged)
+	cloudwatchclient.DropFields(merged, hiddenFields...)
+	return merged, nil
+}
+
+// logGroupBatches splits the sources into batches whose log groups can be searched by one query
+func logGroupBatches(sources []logSource) [][]logSource {
+	var batches [][]logSource
+	batchOf := make(map[string]int)
+	groups := 0
+	for _, source := range sources {
+		i, ok := batchOf[source.logGroup]
+		if !ok {
+			if groups%cloudwatchclient.MaxQueryLogGroups == 0 {
+				batches = append(batches, nil)
+			}
+			i = len(batches) - 1
+			batchOf[source.logGroup] = i
+			groups++
+		}
+		batches[i] = append(batches[i], source)
+	}
+	return batches
+}
+
+// batchLogGroups returns the log groups of the sources of a batch
+func batchLogGroups(batch []logSource) []string {
+	var logGroups []string
+	for _, source := range batch {
+		logGroups = appendUnique(logGroups, source.logGroup)
+	}
+	return logGroups
+}
+
+// sourceOf returns the source whose log group and stream prefix match the event. Of several
+// matching prefixes, the longest wins.
+func sourceOf(sources []logSource, event []cwTypes.ResultField) (logSource, bool) {
+	value, _ := cloudwatchclient.FieldValue(event, "@log")
+	logGroup := cloudwatchclient.LogGroupName(value)
+	logStream, _ := cloudwatchclient.FieldValue(event, "@logStream")
+
+	var found logSource
+	ok := false
+	for _, source := range sources {
+		if source.logGroup != logGroup || !strings.HasPrefix(logStream, source.logStreamPrefix+"/") {
+			continue
+		}
+		if !ok || len(source.logStreamPrefix) > len(found.logStreamPrefix) {
+			found, ok = source, true
+		}
+	}
+	return found, ok
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
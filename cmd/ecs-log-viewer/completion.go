@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/completion"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+)
+
+// runCompletion prints the completion script of the given shell
+func runCompletion(c *cli.Context) error {
+	script, err := completion.Script(c.Args().First(), c.App.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runComplete prints the suggestions for the value of --taskdef, --container or --fields
+// called by the completion scripts. Errors are not reported, since the output is read by the shell.
+func runComplete(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	// prompts and progress messages must not reach the shell
+	log.SetOutput(io.Discard)
+
+	kind, prefix := c.Args().Get(0), c.Args().Get(1)
+	values, err := completionValues(ctx, runOption, kind)
+	if err != nil {
+		return nil
+	}
+
+	suggestions := completion.Filter(values, prefix)
+	if kind == "fields" {
+		suggestions = completion.FilterList(values, prefix)
+	}
+	for _, suggestion := range suggestions {
+		fmt.Println(suggestion)
+	}
+	return nil
+}
+
+// completionValues returns the values of a kind of flag, from the cache when it is fresh
+func completionValues(ctx context.Context, runOption AppOption, kind string) ([]string, error) {
+	profile, region := firstOrEmpty(runOption.profiles), firstOrEmpty(runOption.regions)
+	key := []string{kind, profile, region, runOption.roleArn}
+	switch kind {
+	case "taskdef":
+	case "container":
+		if runOption.taskdef == "" {
+			return nil, fmt.Errorf("--taskdef is required to complete containers")
+		}
+		key = append(key, runOption.taskdef)
+	case "fields":
+		if runOption.taskdef == "" || runOption.container == "" {
+			return nil, fmt.Errorf("--taskdef and --container are required to complete fields")
+		}
+		key = append(key, runOption.taskdef, runOption.container)
+	default:
+		return nil, fmt.Errorf("unknown completion: %s", kind)
+	}
+
+	var cache *completion.Cache
+	if dir, err := completion.DefaultCacheDir(); err == nil {
+		cache = &completion.Cache{Dir: dir, TTL: completion.DefaultTTL}
+		if values, ok := cache.Get(key...); ok {
+			return values, nil
+		}
+	}
+
+	cfg, err := setupAWSConfig(ctx, runOption, profile, region)
+	if err != nil {
+		return nil, err
+	}
+	values, err := lookupCompletionValues(ctx, cfg, runOption, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		// a failed cache write only makes the next completion slower
+		_ = cache.Set(values, key...)
+	}
+	return values, nil
+}
+
+// lookupCompletionValues looks up the values of a kind of flag from AWS
+func lookupCompletionValues(ctx context.Context, cfg aws.Config, runOption AppOption, kind string) ([]string, error) {
+	ecsClient := ecsclient.NewEcsClient(ctx, &cfg)
+
+	if kind == "taskdef" {
+		families, err := ecsClient.ListTaskDefinitionFamilies()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, len(families))
+		for i, family := range families {
+			values[i] = family.Name
+		}
+		return values, nil
+	}
+
+	taskDef, err := ecsClient.DescribeLatestTaskDefinition(ecsclient.TaskDefFamily{Name: runOption.taskdef})
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == "container" {
+		values := make([]string, len(taskDef.ContainerDefinitions))
+		for i, container := range taskDef.ContainerDefinitions {
+			values[i] = aws.ToString(container.Name)
+		}
+		return values, nil
+	}
+
+	var containerDef *ecsTypes.ContainerDefinition
+	for i, container := range taskDef.ContainerDefinitions {
+		if aws.ToString(container.Name) == runOption.container {
+			containerDef = &taskDef.ContainerDefinitions[i]
+		}
+	}
+	if containerDef == nil {
+		return nil, fmt.Errorf("cannot find container: %s", runOption.container)
+	}
+	logGroup, _, err := getLogConfiguration(containerDef)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := cloudwatchclient.NewCloudWatchClient(ctx, &cfg).LogGroupFields(logGroup)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = field.Name
+	}
+	return values, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/selector"
+)
+
+// runCrashes lists recently stopped tasks with their stop reasons and container exit codes,
+// then shows the logs of the selected task leading up to its stop
+func runCrashes(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+	if err := runOption.validateFormat(); err != nil {
+		return err
+	}
+	if len(runOption.profiles) > 1 || len(runOption.regions) > 1 {
+		return fmt.Errorf("crashes can only be used with a single profile and region")
+	}
+
+	cfg, err := setupAWSConfig(ctx, runOption, firstOrEmpty(runOption.profiles), firstOrEmpty(runOption.regions))
+	if err != nil {
+		return err
+	}
+	ecsClient := ecsclient.NewEcsClient(ctx, &cfg)
+	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &cfg)
+
+	cluster, err := selectCluster(ecsClient, runOption)
+	if err != nil {
+		return err
+	}
+
+	family := runOption.taskdef
+	if runOption.service == "" && family == "" {
+		families, err := ecsClient.ListTaskDefinitionFamilies()
+		if err != nil {
+			return fmt.Errorf("failed to list task definition families: %v", err)
+		}
+		if len(families) == 0 {
+			return fmt.Errorf("no task definition families found")
+		}
+		selected, err := selector.SelectItem(families, "Select Task Definition Family > ")
+		if err != nil {
+			return fmt.Errorf("task definition family selection aborted: %v", err)
+		}
+		family = selected.Name
+	}
+
+	tasks, err := ecsClient.ListStoppedTasks(cluster, runOption.service, family)
+	if err != nil {
+		return fmt.Errorf("failed to list stopped tasks: %v", err)
+	}
+	if len(tasks) == 0 {
+		log.Println("No recently stopped tasks found")
+		return nil
+	}
+	if limit := c.Int("max-tasks"); limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	if c.Bool("list") {
+		return writeStoppedTasks(os.Stdout, tasks)
+	}
+	// the logs of the selected task go to stdout
+	if err := writeStoppedTasks(os.Stderr, tasks); err != nil {
+		return err
+	}
+
+	task, err := selectStoppedTask(tasks, c.String("task"))
+	if err != nil {
+		return err
+	}
+
+	taskDef, err := ecsClient.DescribeTaskDefinition(aws.ToString(task.TaskDefinitionArn))
+	if err != nil {
+		return fmt.Errorf("failed to describe task definition: %v", err)
+	}
+	containerDef, err := selectCrashedContainer(taskDef, task, runOption.container)
+	if err != nil {
+		return err
+	}
+
+	logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
+	if err != nil {
+		return err
+	}
+	logStream := logStreamPrefix + "/" + task.ID()
+
+	// a task that is still stopping has no stop time yet
+	stoppedAt := time.Now()
+	if task.StoppedAt != nil {
+		stoppedAt = *task.StoppedAt
+	}
+	// logs may be delivered slightly after the task is reported as stopped
+	endTime := stoppedAt.Add(time.Minute)
+	startTime := endTime.Add(-c.Duration("before") - time.Minute)
+
+	log.Printf("Fetching logs from log group: %s, stream: %s\n", logGroup, logStream)
+	log.Printf("Time range: %s to %s\n", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+
+	order, err := runOption.resultOrder()
+	if err != nil {
+		return err
+	}
+	queryFields, hiddenFields := runOption.fields, []string(nil)
+	if order.Enabled() {
+		queryFields, hiddenFields = cloudwatchclient.IncludeFields(queryFields, "@timestamp")
+	}
+
+	query := cloudwatchclient.BuildCloudWatchQuery(logStream, queryFields, runOption.filter) + order.QueryCommands()
+	results, err := logsClient.QueryLogs(logGroup, query, startTime, endTime)
+	if err != nil {
+		return fmt.Errorf("failed to query logs: %v", err)
+	}
+	results = order.Apply(results)
+	cloudwatchclient.DropFields(results, hiddenFields...)
+
+	if len(results) == 0 {
+		log.Println("No logs found in the specified time range")
+		return nil
+	}
+
+	return writeResults(results, runOption)
+}
+
+// writeStoppedTasks prints a table of stopped tasks with their stop reasons and container exits
+func writeStoppedTasks(w io.Writer, tasks []ecsclient.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "TASK\tSTOPPED AT\tSTOP CODE\tSTOPPED REASON\tCONTAINERS"); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			task.ID(),
+			task.StoppedTime(),
+			task.StopCode,
+			aws.ToString(task.StoppedReason),
+			task.ContainerExits(),
+		); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// selectStoppedTask returns the task with the given ID, or prompts the user to select one
+func selectStoppedTask(tasks []ecsclient.Task, taskID string) (ecsclient.Task, error) {
+	if taskID == "" {
+		task, err := selector.SelectItem(tasks, "Select Task > ")
+		if err != nil {
+			return ecsclient.Task{}, fmt.Errorf("task selection aborted: %v", err)
+		}
+		return task, nil
+	}
+
+	for _, task := range tasks {
+		if task.ID() == taskID || aws.ToString(task.TaskArn) == taskID {
+			return task, nil
+		}
+	}
+	return ecsclient.Task{}, fmt.Errorf("Cannot find stopped task: %s", taskID)
+}
+
+// selectCrashedContainer returns the definition of the named container. Without a name, the
+// container that exited with a non-zero code is used; if there is not exactly one, the user is prompted.
+func selectCrashedContainer(taskDef *ecsTypes.TaskDefinition, task ecsclient.Task, name string) (*ecsTypes.ContainerDefinition, error) {
+	if name == "" {
+		var failed []string
+		for _, container := range task.Containers {
+			if container.ExitCode != nil && *container.ExitCode != 0 {
+				failed = append(failed, aws.ToString(container.Name))
+			}
+		}
+		if len(failed) == 1 {
+			name = failed[0]
+		}
+	}
+
+	if name == "" {
+		containerDef, err := selector.SelectContainerDefinition(taskDef.ContainerDefinitions, "Select Container Definition > ")
+		if err != nil {
+			return nil, fmt.Errorf("container definition selection aborted: %v", err)
+		}
+		return &containerDef, nil
+	}
+
+	for _, container := range taskDef.ContainerDefinitions {
+		if aws.ToString(container.Name) == name {
+			return &container, nil
+		}
+	}
+	return nil, fmt.Errorf("Cannot find container: %s", name)
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
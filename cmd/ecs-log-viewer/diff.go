@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/patterns"
+)
+
+// timeWindow is a time range to fetch logs from
+type timeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+func (w timeWindow) String() string {
+	return fmt.Sprintf("%s to %s", w.start.Format(time.RFC3339), w.end.Format(time.RFC3339))
+}
+
+// runDiff compares the message patterns of two time windows and reports new, gone and changed patterns
+func runDiff(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+	if err := patterns.ValidateThreshold(c.Float64("threshold")); err != nil {
+		return fmt.Errorf("--threshold: %v", err)
+	}
+
+	targets, err := setupAWSTargets(ctx, runOption)
+	if err != nil {
+		return err
+	}
+
+	runOption, _, err = selectContainer(ctx, targets, runOption)
+	if err != nil {
+		return err
+	}
+
+	// every target has its own deployment history, so the windows are resolved per target
+	fields := []string{"@timestamp", "@message"}
+	var baseResults, targetResults [][]cwTypes.ResultField
+	var baseDuration, targetDuration time.Duration
+	for _, t := range targets {
+		base, target, err := diffWindows(ctx, c, t, runOption)
+		if err != nil {
+			return err
+		}
+
+		if len(targets) > 1 {
+			log.Printf("Region %s, account %s:\n", t.cfg.Region, t.account)
+		}
+		log.Printf("Before: %s\n", base)
+		log.Printf("After:  %s\n", target)
+
+		results, err := fetchLogs(ctx, []awsTarget{t}, runOption, fields, base.start, base.end)
+		if err != nil {
+			return err
+		}
+		baseResults = append(baseResults, results...)
+		baseDuration += base.end.Sub(base.start)
+
+		results, err = fetchLogs(ctx, []awsTarget{t}, runOption, fields, target.start, target.end)
+		if err != nil {
+			return err
+		}
+		targetResults = append(targetResults, results...)
+		targetDuration += target.end.Sub(target.start)
+	}
+
+	log.Printf("Comparing %d events before with %d events after\n", len(baseResults), len(targetResults))
+
+	changes := patterns.Compare(patterns.ClusterEvents(baseResults), patterns.ClusterEvents(targetResults), patterns.CompareOption{
+		BaseDuration:   baseDuration,
+		TargetDuration: targetDuration,
+		Threshold:      c.Float64("threshold"),
+		MinCount:       c.Int("min-count"),
+	})
+
+	if len(changes) == 0 {
+		log.Println("No significant changes found")
+		return nil
+	}
+
+	redactor, err := newRedactor(runOption)
+	if err != nil {
+		return err
+	}
+	if redactor != nil {
+		patterns.RedactChanges(changes, redactor.Redact)
+	}
+
+	writer, err := openOutput(runOption.output)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(writer)
+
+	if err := patterns.WriteChanges(writer, changes); err != nil {
+		return fmt.Errorf("failed to write diff report: %v", err)
+	}
+	return nil
+}
+
+// diffWindows returns the base and target windows to compare in a target.
+// With --revisions, the split point is the time the newer revision was deployed and the
+// base window never starts before the older revision was deployed. Otherwise the split
+// point is --at, defaulting to one window ago.
+func diffWindows(ctx context.Context, c *cli.Context, target awsTarget, runOption AppOption) (timeWindow, timeWindow, error) {
+	window := c.Duration("window")
+	now := time.Now()
+
+	if window <= 0 {
+		return timeWindow{}, timeWindow{}, fmt.Errorf("--window must be positive")
+	}
+
+	if revisions := c.IntSlice("revisions"); len(revisions) > 0 {
+		if len(revisions) != 2 {
+			return timeWindow{}, timeWindow{}, fmt.Errorf("--revisions requires exactly two revisions")
+		}
+		older, newer, err := revisionTimes(ecsclient.NewEcsClient(ctx, &target.cfg), runOption, revisions[0], revisions[1])
+		if err != nil {
+			return timeWindow{}, timeWindow{}, err
+		}
+		if !older.Before(newer) {
+			return timeWindow{}, timeWindow{}, fmt.Errorf("revision %d must be deployed before revision %d", revisions[0], revisions[1])
+		}
+
+		baseStart := newer.Add(-window)
+		if baseStart.Before(older) {
+			baseStart = older
+		}
+		targetEnd := newer.Add(window)
+		if targetEnd.After(now) {
+			targetEnd = now
+		}
+		return timeWindow{start: baseStart, end: newer}, timeWindow{start: newer, end: targetEnd}, nil
+	}
+
+	split := now.Add(-window)
+	if at := c.Timestamp("at"); at != nil {
+		split = *at
+	}
+	targetEnd := split.Add(window)
+	if targetEnd.After(now) {
+		targetEnd = now
+	}
+	if !split.Before(targetEnd) {
+		return timeWindow{}, timeWindow{}, fmt.Errorf("--at must be in the past")
+	}
+	return timeWindow{start: split.Add(-window), end: split}, timeWindow{start: split, end: targetEnd}, nil
+}
+
+// revisionTimes returns the times two task definition revisions were first deployed to the service
+// running them. A revision whose deployment cannot be found falls back to its registration time,
+// which may be well before it was deployed.
+func revisionTimes(ecsClient *ecsclient.EcsClient, runOption AppOption, olderRevision, newerRevision int) (time.Time, time.Time, error) {
+	deployed, lookupErr := deploymentTimes(ecsClient, runOption)
+	if lookupErr != nil {
+		log.Printf("Warning: failed to look up the deployments of %s, using registration times instead: %v\n", runOption.taskdef, lookupErr)
+	}
+
+	var times [2]time.Time
+	for i, revision := range []int{olderRevision, newerRevision} {
+		name := runOption.taskdef + ":" + strconv.Itoa(revision)
+		if at, ok := deployed[name]; ok {
+			times[i] = at
+			continue
+		}
+		if lookupErr == nil {
+			log.Printf("Warning: no deployment of %s found, using its registration time instead\n", name)
+		}
+		registeredAt, err := revisionRegisteredAt(ecsClient, name)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		times[i] = registeredAt
+	}
+	return times[0], times[1], nil
+}
+
+// deploymentTimes returns when each task definition revision was first deployed to the service running the family
+func deploymentTimes(ecsClient *ecsclient.EcsClient, runOption AppOption) (map[string]time.Time, error) {
+	cluster, err := selectCluster(ecsClient, runOption)
+	if err != nil {
+		return nil, err
+	}
+	service, err := selectService(ecsClient, cluster, runOption, runOption.taskdef)
+	if err != nil {
+		return nil, err
+	}
+	times, err := ecsClient.DeploymentTimes(cluster, *service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments of service %s: %v", aws.ToString(service.ServiceName), err)
+	}
+	return times, nil
+}
+
+// revisionRegisteredAt returns the time a task definition revision was registered
+func revisionRegisteredAt(ecsClient *ecsclient.EcsClient, name string) (time.Time, error) {
+	taskDef, err := ecsClient.DescribeTaskDefinition(name)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to describe task definition %s: %v", name, err)
+	}
+	if taskDef.RegisteredAt == nil {
+		return time.Time{}, fmt.Errorf("registration time of %s is unknown", name)
+	}
+	return aws.ToTime(taskDef.RegisteredAt), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/logfields"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/selector"
+)
+
+// defaultFieldSample is the number of recent messages sampled for JSON keys
+const defaultFieldSample = 200
+
+// runFields prints the fields available for --fields in the log group of the selected container
+func runFields(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+
+	targets, err := setupAWSTargets(ctx, runOption)
+	if err != nil {
+		return err
+	}
+
+	runOption, containerDef, err := selectContainer(ctx, targets, runOption)
+	if err != nil {
+		return err
+	}
+
+	fields, err := discoverFields(ctx, targets[0], runOption, containerDef, c.Int("sample"))
+	if err != nil {
+		return err
+	}
+
+	writer, err := openOutput(runOption.output)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(writer)
+
+	if err := logfields.WriteFields(writer, fields); err != nil {
+		return fmt.Errorf("failed to write fields: %v", err)
+	}
+	return nil
+}
+
+// discoverFields returns the fields of the container's log group reported by Logs Insights,
+// together with the JSON keys of up to sample recent messages of the container
+func discoverFields(ctx context.Context, target awsTarget, runOption AppOption, containerDef *ecsTypes.ContainerDefinition, sample int) ([]logfields.Field, error) {
+	logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
+	if err != nil {
+		return nil, err
+	}
+	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &target.cfg)
+
+	log.Printf("Discovering fields of log group: %s\n", logGroup)
+	logGroupFields, err := logsClient.LogGroupFields(logGroup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log group fields: %v", err)
+	}
+
+	var jsonFields []logfields.Field
+	if sample > 0 {
+		endTime := time.Now()
+		query := cloudwatchclient.BuildCloudWatchQuery(logStreamPrefix, []string{"@message"}, runOption.filter) +
+			fmt.Sprintf(" | sort @timestamp desc | limit %d", sample)
+		results, err := logsClient.QueryLogs(logGroup, query, endTime.Add(-runOption.duration), endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample messages: %v", err)
+		}
+
+		messages := make([]string, 0, len(results))
+		for _, event := range results {
+			message, _ := cloudwatchclient.FieldValue(event, "@message")
+			messages = append(messages, message)
+		}
+		jsonFields = logfields.DiscoverJSONKeys(messages)
+	}
+
+	return logfields.Merge(logGroupFields, jsonFields), nil
+}
+
+// pickFields lets the user check the fields to fetch from the discovered fields
+func pickFields(ctx context.Context, target awsTarget, runOption AppOption, containerDef *ecsTypes.ContainerDefinition) ([]string, error) {
+	fields, err := discoverFields(ctx, target, runOption, containerDef, defaultFieldSample)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields found in the log group")
+	}
+
+	picked, err := selector.SelectMultiple(fields, "Select Fields > ")
+	if err != nil {
+		return nil, fmt.Errorf("field selection aborted: %v", err)
+	}
+
+	names := make([]string, len(picked))
+	for i, field := range picked {
+		names[i] = field.Name
+	}
+	log.Printf("Selected fields: --fields %s\n", strings.Join(names, ","))
+	return names, nil
+}
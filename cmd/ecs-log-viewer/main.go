@@ -4,7 +4,10 @@ import (
 	"log"
 	"os"
 	"time"
+	// time zones for --tz on systems without a zoneinfo database
+	_ "time/tzdata"
 
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/sqliteexport"
 	"github.com/urfave/cli/v2"
 )
 
@@ -12,19 +15,38 @@ func main() {
 	app := &cli.App{
 		Name:  "ecs-log-viewer",
 		Usage: "Interactive tool for viewing AWS ECS container logs with advanced filtering capabilities",
+		// command and flag names are completed by the scripts of the completion command
+		EnableBashCompletion: true,
 		Flags: []cli.Flag{
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:    "profile",
 				Aliases: []string{"p"},
-				Usage:   "AWS profile name to use for authentication",
+				Usage:   "AWS profile name to use for authentication. Specify multiple (comma-separated) to query each of them",
 				EnvVars: []string{"AWS_PROFILE"},
 			},
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:    "region",
 				Aliases: []string{"r"},
-				Usage:   "AWS region where your ECS clusters are located",
+				Usage:   "AWS region where your ECS clusters are located. Specify multiple (comma-separated) to query each of them",
 				EnvVars: []string{"AWS_REGION"},
 			},
+			&cli.StringFlag{
+				Name:  "role-arn",
+				Usage: "ARN of an IAM role to assume with the loaded credentials (e.g., for cross-account access)",
+			},
+			&cli.StringFlag{
+				Name:  "external-id",
+				Usage: "External ID to pass when assuming the role specified by --role-arn",
+			},
+			&cli.StringFlag{
+				Name:  "mfa-serial",
+				Usage: "Serial number or ARN of the MFA device required by the role. You will be prompted for the token code",
+			},
+			&cli.DurationFlag{
+				Name:  "session-duration",
+				Usage: "Duration of the assumed role session (e.g., 1h, 12h). Sessions are cached until they expire",
+				Value: time.Hour,
+			},
 			&cli.DurationFlag{
 				Name:    "duration",
 				Aliases: []string{"d"},
@@ -51,16 +73,143 @@ func main() {
 				Usage: "Comma-separated list of log fields to display (e.g., @message,@timestamp). Default: @message",
 				Value: cli.NewStringSlice("@message"),
 			},
+			&cli.IntFlag{
+				Name:    "after-context",
+				Aliases: []string{"A"},
+				Usage:   "Show N events logged after each match of --filter in the same log stream, like grep -A",
+			},
+			&cli.IntFlag{
+				Name:    "before-context",
+				Aliases: []string{"B"},
+				Usage:   "Show N events logged before each match of --filter in the same log stream, like grep -B",
+			},
+			&cli.IntFlag{
+				Name:    "context",
+				Aliases: []string{"C"},
+				Usage:   "Show N events logged before and after each match of --filter in the same log stream, like grep -C",
+			},
+			&cli.StringFlag{
+				Name:  "time-format",
+				Usage: "Format of @timestamp in the output: rfc3339, unix, unixms, relative or a Go time layout (e.g. \"2006-01-02 15:04:05\"). Default: as returned by CloudWatch Logs (UTC), or rfc3339 when --tz is given",
+			},
+			&cli.StringFlag{
+				Name:  "tz",
+				Usage: "Time zone of @timestamp in the output: local, UTC or a zone name such as Asia/Tokyo. Default: local when --time-format is given",
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "Order of the events by @timestamp: asc or desc. @timestamp is fetched for sorting even if it is not among --fields",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of events (up to 10000), the first ones in --sort order. Without --sort, the newest events are shown first",
+			},
+			&cli.IntFlag{
+				Name:  "head",
+				Usage: "Show only the N oldest events of the time range (up to 10000)",
+			},
+			&cli.IntFlag{
+				Name:  "tail",
+				Usage: "Show only the N newest events of the time range (up to 10000), oldest first unless --sort desc is given",
+			},
+			&cli.BoolFlag{
+				Name:  "pick-fields",
+				Usage: "Discover the fields of the container's log group and pick the fields to fetch interactively instead of --fields",
+			},
 			&cli.StringFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
-				Usage:   "Output file path for saving logs. Defaults to stdout if not specified.",
+				Usage:   "Output file path for saving logs. Defaults to stdout if not specified. Files ending with .gz or .zst are compressed",
 			},
 			&cli.StringFlag{
 				Name:  "format",
-				Usage: "Output format (simple, csv, json). 'simple' format can only be used when exactly one field is selected",
+				Usage: "Output format (simple, csv, json, jsonl, table, template, sqlite). 'simple' format can only be used when exactly one field is selected",
 				Value: "simple",
 			},
+			&cli.StringFlag{
+				Name:  "template",
+				Usage: "Go template rendering each event for the template format (e.g., '{{.timestamp}} [{{.logStream | short}}] {{.message}}')",
+			},
+			&cli.StringFlag{
+				Name:  "template-file",
+				Usage: "File containing the Go template for the template format",
+			},
+			&cli.StringSliceFlag{
+				Name:  "sink",
+				Usage: "Forward the results to an external system given as type=url instead of stdout: loki=http://localhost:3100, elasticsearch=http://localhost:9200/INDEX (or opensearch=...) or webhook=URL. Specify multiple to forward to each of them",
+			},
+			&cli.IntFlag{
+				Name:  "sink-batch-size",
+				Usage: "Maximum number of events sent to a sink in one request",
+				Value: 500,
+			},
+			&cli.StringFlag{
+				Name:  "sqlite-table",
+				Usage: "Table the sqlite format appends the events to",
+				Value: sqliteexport.DefaultTable,
+			},
+			&cli.StringFlag{
+				Name:  "split-size",
+				Usage: "Split the output file into files of about this size (e.g., 100MB). Files are named with a sequence number (logs-0001.jsonl.gz)",
+			},
+			&cli.IntFlag{
+				Name:  "split-events",
+				Usage: "Split the output file into files of at most this many events",
+			},
+			&cli.BoolFlag{
+				Name:  "split-hourly",
+				Usage: "Split the output file by the hour of @timestamp (logs-2026-10-16T14.jsonl.gz)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-truncate",
+				Usage: "Do not truncate long values of the table format to the terminal width",
+			},
+			&cli.StringFlag{
+				Name:  "multiline-start",
+				Usage: "Merge continuation lines into the preceding event of the same stream. Either a regex matching the first line of an event or a preset (java, python, go)",
+			},
+			&cli.BoolFlag{
+				Name:  "histogram",
+				Usage: "Draw a bar chart of the log volume over the time range to stderr before the output",
+			},
+			&cli.BoolFlag{
+				Name:  "histogram-levels",
+				Usage: "Like --histogram, but split each bar by the detected log level (error, warn, info, debug)",
+			},
+			&cli.IntFlag{
+				Name:  "histogram-buckets",
+				Usage: "Number of time buckets drawn by --histogram",
+				Value: 30,
+			},
+			&cli.StringFlag{
+				Name:  "cluster",
+				Usage: "ECS cluster name. If not specified and required, you will be prompted to select one interactively",
+			},
+			&cli.StringFlag{
+				Name:  "service",
+				Usage: "ECS service name. If not specified and required, the service running the selected task definition is used",
+			},
+			&cli.BoolFlag{
+				Name:  "all-services",
+				Usage: "Query the logs of all containers of every service in --cluster with their current task definitions at once, instead of selecting a task definition and container. --container limits the containers by name",
+			},
+			&cli.BoolFlag{
+				Name:  "service-events",
+				Usage: "Interleave the ECS service events and deployments of the service with the logs",
+			},
+			&cli.BoolFlag{
+				Name:  "tui",
+				Usage: "Browse the results in a full-screen terminal viewer with search, column toggles, a detail pane and re-running with a changed filter or time range",
+			},
+			&cli.BoolFlag{
+				Name:  "redact",
+				Usage: "Replace secrets and personal information (AWS keys, JWTs, bearer tokens, emails, credit card numbers, IPs) and values matching the rules in the config file with placeholders",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "Path to the config file. Defaults to ecs-log-viewer/config.json in the user config directory",
+				EnvVars: []string{"ECS_LOG_VIEWER_CONFIG"},
+			},
 			&cli.BoolFlag{
 				Name:    "web",
 				Aliases: []string{"w"},
@@ -69,6 +218,183 @@ func main() {
 			},
 		},
 		Action: runApp,
+		Commands: []*cli.Command{
+			{
+				Name:  "analyze",
+				Usage: "Analyze the fetched logs instead of printing them",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "patterns",
+						Usage: "Group log messages into templates by normalizing variable tokens (numbers, UUIDs, IPs, hex IDs) and report them by frequency",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "top",
+								Usage: "Only show the N most frequent patterns. 0 shows all patterns",
+							},
+						},
+						Action: runAnalyzePatterns,
+					},
+				},
+			},
+			{
+				Name:  "diff",
+				Usage: "Compare the message patterns of two time windows (e.g., before and after a deploy) and report new, gone and changed patterns",
+				Flags: []cli.Flag{
+					&cli.TimestampFlag{
+						Name:   "at",
+						Usage:  "Time splitting the two windows in RFC3339 format (e.g., 2025-02-16T10:00:00Z). Defaults to one window ago",
+						Layout: time.RFC3339,
+					},
+					&cli.DurationFlag{
+						Name:  "window",
+						Usage: "Length of each window before and after the split time",
+						Value: time.Hour,
+					},
+					&cli.IntSliceFlag{
+						Name:  "revisions",
+						Usage: "Two task definition revisions (e.g., 41,42). The windows are split at the time the second revision was deployed",
+					},
+					&cli.Float64Flag{
+						Name:  "threshold",
+						Usage: "Minimum change of the rate of a pattern to be reported, greater than 1 (e.g., 2 reports patterns at least twice or half as frequent)",
+						Value: 2,
+					},
+					&cli.IntFlag{
+						Name:  "min-count",
+						Usage: "Ignore patterns seen less often than this in both windows",
+						Value: 3,
+					},
+				},
+				Action: runDiff,
+			},
+			{
+				Name:  "watch",
+				Usage: "Periodically query the logs of the selected container and alert when rules trigger, e.g. while watching a canary deploy",
+				Flags: []cli.Flag{
+					&cli.GenericFlag{
+						Name:  "rule",
+						Usage: "Alert rule, repeatable: '/PATTERN/ > N in WINDOW' alerts when more than N messages in the window match (e.g., '/ERROR/ > 20 in 5m'), '/PATTERN/' alerts on any new match. Append i for case-insensitive patterns (/timeout/i)",
+						Value: &ruleList{},
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "How often to query the logs",
+						Value: time.Minute,
+					},
+					&cli.BoolFlag{
+						Name:  "notify",
+						Usage: "Show alerts as desktop notifications (notify-send on Linux, osascript on macOS)",
+					},
+					&cli.StringFlag{
+						Name:  "exec",
+						Usage: "Shell command run for each alert, with ECS_LOG_VIEWER_RULE, ECS_LOG_VIEWER_COUNT, ECS_LOG_VIEWER_MESSAGE and ECS_LOG_VIEWER_SAMPLE set",
+					},
+					&cli.StringFlag{
+						Name:  "webhook",
+						Usage: "URL each alert is posted to as JSON",
+					},
+				},
+				Action: runWatch,
+			},
+			{
+				Name:  "metrics-exporter",
+				Usage: "Periodically run the Logs Insights queries defined in the metrics section of the config file and expose the results on /metrics for Prometheus",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "listen",
+						Usage: "Address to serve /metrics on",
+						Value: "127.0.0.1:9464",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "How often to run each query. Every run is billed by the amount of log data scanned",
+						Value: time.Minute,
+					},
+				},
+				Action: runMetricsExporter,
+			},
+			{
+				Name:      "completion",
+				Usage:     "Print the shell completion script for bash, zsh or fish. --taskdef, --container and --fields are completed from your AWS account",
+				ArgsUsage: "bash|zsh|fish",
+				Action:    runCompletion,
+			},
+			{
+				Name:   "__complete",
+				Hidden: true,
+				Usage:  "Print the suggestions for the value of --taskdef, --container or --fields",
+				Action: runComplete,
+			},
+			{
+				Name:  "fields",
+				Usage: "List the fields available for --fields in the log group of the selected container with their frequency, including the keys of recent JSON messages",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "sample",
+						Usage: "Number of recent messages within --duration whose JSON keys are discovered. 0 only lists the fields reported by Logs Insights",
+						Value: defaultFieldSample,
+					},
+				},
+				Action: runFields,
+			},
+			{
+				Name:      "trace",
+				Usage:     "Search the logs of several task definition families in parallel for a request or trace ID (including X-Ray trace headers and W3C traceparent) and show the matching events of all services in chronological order",
+				ArgsUsage: "<id>",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "families",
+						Usage: "Task definition families to search (comma-separated). If not specified, you will be prompted to select them interactively. All containers logging to CloudWatch Logs are searched unless --container is given",
+					},
+				},
+				Action: runTrace,
+			},
+			{
+				Name:  "crashes",
+				Usage: "List recently stopped tasks of a service or task definition family with their stop reasons and container exit codes, and show the logs of a selected task before it stopped",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "max-tasks",
+						Usage: "Maximum number of stopped tasks to list",
+						Value: 20,
+					},
+					&cli.DurationFlag{
+						Name:  "before",
+						Usage: "How long before the task stopped to fetch logs from",
+						Value: 15 * time.Minute,
+					},
+					&cli.StringFlag{
+						Name:  "task",
+						Usage: "ID of the stopped task to show logs for. If not specified, you will be prompted to select one interactively",
+					},
+					&cli.BoolFlag{
+						Name:  "list",
+						Usage: "Only list the stopped tasks without showing logs",
+					},
+				},
+				Action: runCrashes,
+			},
+			{
+				Name:  "serve",
+				Usage: "Start a local HTTP server with a web UI to select a task definition and container and browse their logs",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "listen",
+						Usage: "Address to listen on. The web UI has no authentication, so it listens on the loopback interface by default",
+						Value: "127.0.0.1:8080",
+					},
+					&cli.StringSliceFlag{
+						Name:  "allowed-host",
+						Usage: "Host names the web UI may be reached by besides localhost and the --listen address, e.g. behind a reverse proxy (comma-separated)",
+					},
+					&cli.BoolFlag{
+						Name:  "open",
+						Usage: "Open the web UI in the default browser",
+					},
+				},
+				Action: runServe,
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
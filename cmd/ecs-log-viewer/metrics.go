@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	appConfig "github.com/bonyuta0204/ecs-log-viewer/pkg/config"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/metrics"
+)
+
+// defaultMetricWindow is the time range of a metric query that does not set one
+const defaultMetricWindow = 5 * time.Minute
+
+// Timeouts of the /metrics server
+const (
+	metricsReadTimeout  = 10 * time.Second
+	metricsWriteTimeout = 30 * time.Second
+	metricsIdleTimeout  = 2 * time.Minute
+)
+
+// metricQuery is a validated metric definition of the config file
+type metricQuery struct {
+	appConfig.MetricConfig
+	window time.Duration
+}
+
+// runMetricsExporter periodically runs the metric queries of the config file and
+// serves their latest results on /metrics in the Prometheus text format
+func runMetricsExporter(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+
+	cfg, err := appConfig.Load(runOption.configPath)
+	if err != nil {
+		return err
+	}
+	queries, err := metricQueries(cfg.Metrics)
+	if err != nil {
+		return err
+	}
+
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	targets, err := setupAWSTargets(ctx, runOption)
+	if err != nil {
+		return err
+	}
+
+	registry := metrics.NewRegistry()
+	for _, query := range queries {
+		go func(query metricQuery) {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				results, err := runMetricQuery(ctx, targets, runOption, query)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					log.Printf("Warning: metric query %s failed: %v\n", query.Name, err)
+				}
+				registry.Update(query.Name, results, err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(query)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", c.String("listen"), err)
+	}
+	log.Printf("Serving metrics of %d queries at http://%s/metrics every %s (press Ctrl+C to stop)\n", len(queries), listener.Addr(), interval)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadTimeout,
+		ReadTimeout:       metricsReadTimeout,
+		WriteTimeout:      metricsWriteTimeout,
+		IdleTimeout:       metricsIdleTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsReadTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Warning: failed to shut down the server: %v\n", err)
+		}
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// metricQueries validates the metric definitions of the config file
+func metricQueries(configs []appConfig.MetricConfig) ([]metricQuery, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no metrics defined: add metrics to the config file")
+	}
+
+	queries := make([]metricQuery, len(configs))
+	names := make(map[string]bool)
+	for i, metric := range configs {
+		if metric.Name == "" || metric.TaskDef == "" || metric.Container == "" || metric.Query == "" {
+			return nil, fmt.Errorf("metric %d: name, taskdef, container and query are required", i+1)
+		}
+		if names[metric.Name] {
+			return nil, fmt.Errorf("metric %s is defined more than once", metric.Name)
+		}
+		names[metric.Name] = true
+
+		window := defaultMetricWindow
+		if metric.Window != "" {
+			var err error
+			window, err = time.ParseDuration(metric.Window)
+			if err != nil || window <= 0 {
+				return nil, fmt.Errorf("metric %s: invalid window %q", metric.Name, metric.Window)
+			}
+		}
+		queries[i] = metricQuery{MetricConfig: metric, window: window}
+	}
+	return queries, nil
+}
+
+// runMetricQuery runs a metric query over its window in every target and converts the results
+func runMetricQuery(ctx context.Context, targets []awsTarget, runOption AppOption, query metricQuery) ([]metrics.Metric, error) {
+	queryOption := runOption
+	queryOption.taskdef = query.TaskDef
+	queryOption.container = query.Container
+
+	endTime := time.Now()
+	results, err := queryTargets(ctx, targets, queryOption, logQuery{
+		build: func(logStreamPrefix string) string {
+			return cloudwatchclient.BuildStatsQuery(logStreamPrefix, query.Filter, query.Query)
+		},
+	}, endTime.Add(-query.window), endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := query.Labels
+	if len(targets) > 1 {
+		// results of several targets are told apart by the columns queryTargets adds
+		labels = append(labels[:len(labels):len(labels)], "region", "account")
+	}
+	constLabels := map[string]string{
+		"task_definition": query.TaskDef,
+		"container":       query.Container,
+	}
+	return metrics.FromResults(query.Name, query.Help, results, labels, constLabels), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/server"
+)
+
+// Timeouts of the web UI server. Responses of /api/logs wait for the Logs Insights query to complete.
+const (
+	serveReadTimeout  = 30 * time.Second
+	serveWriteTimeout = 5 * time.Minute
+	serveIdleTimeout  = 2 * time.Minute
+)
+
+// serverBackend serves the task definitions and logs of a single AWS account and region
+type serverBackend struct {
+	ecsClient  *ecsclient.EcsClient
+	logsClient *cloudwatchclient.CloudWatchClient
+}
+
+// TaskDefinitionFamilies implements server.Backend
+func (b *serverBackend) TaskDefinitionFamilies() ([]string, error) {
+	families, err := b.ecsClient.ListTaskDefinitionFamilies()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(families))
+	for i, family := range families {
+		names[i] = family.Name
+	}
+	return names, nil
+}
+
+// Containers implements server.Backend
+func (b *serverBackend) Containers(family string) ([]string, error) {
+	taskDef, err := b.ecsClient.DescribeLatestTaskDefinition(ecsclient.TaskDefFamily{Name: family})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, container := range taskDef.ContainerDefinitions {
+		names = append(names, aws.ToString(container.Name))
+	}
+	return names, nil
+}
+
+// QueryLogs implements server.Backend
+func (b *serverBackend) QueryLogs(family, container string, fields []string, filter string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	_, containerDef, err := selectTaskAndContainer(b.ecsClient, AppOption{taskdef: family, container: container})
+	if err != nil {
+		return nil, err
+	}
+	logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching logs from log group: %s, stream prefix: %s\n", logGroup, logStreamPrefix)
+	query := cloudwatchclient.BuildCloudWatchQuery(logStreamPrefix, fields, filter)
+	return b.logsClient.QueryLogs(logGroup, query, startTime, endTime)
+}
+
+// runServe starts a local HTTP server with a web UI for browsing logs
+func runServe(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+	if len(runOption.profiles) > 1 || len(runOption.regions) > 1 {
+		return fmt.Errorf("serve can only be used with a single profile and region")
+	}
+
+	cfg, err := setupAWSConfig(ctx, runOption, firstOrEmpty(runOption.profiles), firstOrEmpty(runOption.regions))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", c.String("listen"), err)
+	}
+
+	allowedHosts := c.StringSlice("allowed-host")
+	if host, _, err := net.SplitHostPort(c.String("listen")); err == nil {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			log.Println("Warning: the web UI has no authentication. Anyone who can reach it can read the logs")
+		}
+		// the listen address is an allowed host unless it listens on all addresses
+		if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+			allowedHosts = append(allowedHosts, host)
+		}
+	}
+
+	redactor, err := newRedactor(runOption)
+	if err != nil {
+		return err
+	}
+
+	handler := server.New(&serverBackend{
+		ecsClient:  ecsclient.NewEcsClient(ctx, &cfg),
+		logsClient: cloudwatchclient.NewCloudWatchClient(ctx, &cfg),
+	}, server.Options{AllowedHosts: allowedHosts, Redactor: redactor})
+
+	url := "http://" + listener.Addr().String()
+	log.Printf("Serving log viewer at %s (press Ctrl+C to stop)\n", url)
+	if c.Bool("open") {
+		if err := open(url); err != nil {
+			log.Printf("Warning: failed to open browser: %v\n", err)
+		}
+	}
+
+	httpServer := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: serveReadTimeout,
+		ReadTimeout:       serveReadTimeout,
+		WriteTimeout:      serveWriteTimeout,
+		IdleTimeout:       serveIdleTimeout,
+	}
+	return httpServer.Serve(listener)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/selector"
+)
+
+// selectCluster returns the cluster from the options, or prompts the user to select one
+func selectCluster(ecsClient *ecsclient.EcsClient, appOption AppOption) (string, error) {
+	if appOption.cluster != "" {
+		return appOption.cluster, nil
+	}
+
+	clusters, err := ecsClient.ListClusters()
+	if err != nil {
+		return "", fmt.Errorf("failed to list clusters: %v", err)
+	}
+	if len(clusters) == 0 {
+		return "", fmt.Errorf("no clusters found")
+	}
+
+	cluster, err := selector.SelectItem(clusters, "Select Cluster > ")
+	if err != nil {
+		return "", fmt.Errorf("cluster selection aborted: %v", err)
+	}
+	return cluster.Arn, nil
+}
+
+// selectService returns the service from the options, or selects a service of the cluster.
+// Only services running the given task definition family are offered; if there is exactly
+// one, it is selected without prompting.
+func selectService(ecsClient *ecsclient.EcsClient, cluster string, appOption AppOption, family string) (*ecsclient.Service, error) {
+	serviceNames := []string{appOption.service}
+	if appOption.service == "" {
+		var err error
+		serviceNames, err = ecsClient.ListServices(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %v", err)
+		}
+		if len(serviceNames) == 0 {
+			return nil, fmt.Errorf("no services found in cluster %s", cluster)
+		}
+	}
+
+	services, err := ecsClient.DescribeServices(cluster, serviceNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe services: %v", err)
+	}
+	if appOption.service != "" {
+		return &services[0], nil
+	}
+
+	var candidates []ecsclient.Service
+	for _, service := range services {
+		if ecsclient.TaskDefinitionFamily(aws.ToString(service.TaskDefinition)) == family {
+			candidates = append(candidates, service)
+		}
+	}
+	if len(candidates) == 1 {
+		return &candidates[0], nil
+	}
+	if len(candidates) == 0 {
+		candidates = services
+	}
+
+	service, err := selector.SelectItem(candidates, "Select Service > ")
+	if err != nil {
+		return nil, fmt.Errorf("service selection aborted: %v", err)
+	}
+	return &service, nil
+}
+
+// serviceEventRows converts the events and deployments of a service within the time range into
+// rows with the given fields, so that they can be merged with log events.
+// Messages are prefixed to distinguish them from the application's logs.
+func serviceEventRows(service *ecsclient.Service, fields []string, startTime, endTime time.Time) [][]cwTypes.ResultField {
+	var rows [][]cwTypes.ResultField
+	add := func(at *time.Time, message string) {
+		if at == nil || at.Before(startTime) || at.After(endTime) {
+			return
+		}
+		rows = append(rows, serviceEventRow(fields, *at, aws.ToString(service.ServiceName), message))
+	}
+
+	for _, event := range service.Events {
+		add(event.CreatedAt, "[ECS service event] "+aws.ToString(event.Message))
+	}
+
+	for _, deployment := range service.Deployments {
+		taskDef := ecsclient.ResourceName(aws.ToString(deployment.TaskDefinition))
+		add(deployment.CreatedAt, fmt.Sprintf("[ECS deployment] %s created: task definition %s, status %s, desired %d",
+			aws.ToString(deployment.Id), taskDef, aws.ToString(deployment.Status), deployment.DesiredCount))
+
+		if deployment.UpdatedAt != nil && deployment.CreatedAt != nil && deployment.UpdatedAt.After(*deployment.CreatedAt) {
+			message := fmt.Sprintf("[ECS deployment] %s updated: status %s, running %d/%d, failed %d, rollout %s",
+				aws.ToString(deployment.Id), aws.ToString(deployment.Status), deployment.RunningCount, deployment.DesiredCount,
+				deployment.FailedTasks, deployment.RolloutState)
+			if reason := aws.ToString(deployment.RolloutStateReason); reason != "" {
+				message += ": " + reason
+			}
+			add(deployment.UpdatedAt, message)
+		}
+	}
+
+	return rows
+}
+
+func serviceEventRow(fields []string, at time.Time, serviceName, message string) []cwTypes.ResultField {
+	row := make([]cwTypes.ResultField, 0, len(fields))
+	for _, field := range fields {
+		var value string
+		switch field {
+		case "@timestamp":
+			value = at.UTC().Format(cloudwatchclient.TimestampLayout)
+		case "@message":
+			value = message
+		case "@logStream":
+			value = "ecs-service/" + serviceName
+		}
+		row = append(row, cwTypes.ResultField{Field: aws.String(field), Value: aws.String(value)})
+	}
+	return row
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+)
+
+// logQuery describes how to query the logs of a container in each target
+type logQuery struct {
+	// build returns the Insights query for the container's log stream prefix
+	build func(logStreamPrefix string) string
+	// process post-processes the results of a single target before they are merged. Optional.
+	process func(events [][]cwTypes.ResultField) [][]cwTypes.ResultField
+}
+
+// logSource is a container whose logs are queried together with those of other services
+type logSource struct {
+	// service is the service or task definition family shown in the service column
+	service         string
+	container       string
+	logGroup        string
+	logStreamPrefix string
+}
+
+// containerSources returns the containers of a task definition logging to CloudWatch Logs. When container
+// is given, only containers of that name are returned.
+func containerSources(service string, taskDef *ecsTypes.TaskDefinition, container string) []logSource {
+	var sources []logSource
+	for i := range taskDef.ContainerDefinitions {
+		containerDef := &taskDef.ContainerDefinitions[i]
+		name := aws.ToString(containerDef.Name)
+		if container != "" && name != container {
+			continue
+		}
+		logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
+		if err != nil {
+			log.Printf("Skipping container %s of %s: %v\n", name, service, err)
+			continue
+		}
+		sources = append(sources, logSource{
+			service:         service,
+			container:       name,
+			logGroup:        logGroup,
+			logStreamPrefix: logStreamPrefix,
+		})
+	}
+	return sources
+}
+
+// awsTarget is a single profile and region combination to fetch logs from
+type awsTarget struct {
+	profile string
+	account string
+	cfg     aws.Config
+}
+
+// setupAWSTargets loads an AWS config for every combination of the given profiles and regions.
+// The account ID of each target is only looked up when more than one target is configured,
+// since it is used to label merged results.
+func setupAWSTargets(ctx context.Context, runOption AppOption) ([]awsTarget, error) {
+	profiles := runOption.profiles
+	if len(profiles) == 0 {
+		profiles = []string{""}
+	}
+	regions := runOption.regions
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	var targets []awsTarget
+	for _, profile := range profiles {
+		for _, region := range regions {
+			cfg, err := setupAWSConfig(ctx, runOption, profile, region)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, awsTarget{profile: profile, cfg: cfg})
+		}
+	}
+
+	if len(targets) > 1 {
+		for i := range targets {
+			identity, err := sts.NewFromConfig(targets[i].cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get caller identity for profile %q: %v", targets[i].profile, err)
+			}
+			targets[i].account = aws.ToString(identity.Account)
+		}
+	}
+
+	return targets, nil
+}
+
+// queryTargets resolves the log configuration of the selected container in each target and
+// runs the queries concurrently. When there is more than one target, the results are merged
+// in timestamp order and labelled with region and account columns.
+func queryTargets(ctx context.Context, targets []awsTarget, runOption AppOption, query logQuery, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	results := make([][][]cwTypes.ResultField, len(targets))
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target awsTarget) {
+			defer wg.Done()
+			results[i], errs[i] = queryTarget(ctx, target, runOption, query, startTime, endTime)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			if len(targets) == 1 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("region %s, account %s: %v", targets[i].cfg.Region, targets[i].account, err)
+		}
+	}
+
+	if len(targets) == 1 {
+		return results[0], nil
+	}
+
+	var merged [][]cwTypes.ResultField
+	for i, target := range targets {
+		cloudwatchclient.AddField(results[i], "region", target.cfg.Region)
+		cloudwatchclient.AddField(results[i], "account", target.account)
+		merged = append(merged, results[i]...)
+	}
+	cloudwatchclient.SortByTimestamp(merged)
+	return merged, nil
+}
+
+// warnQueryLimit warns when a query returned as many results as Logs Insights returns at most,
+// since the events beyond them are silently left out
+func warnQueryLimit(results [][]cwTypes.ResultField, source string) {
+	if len(results) >= cloudwatchclient.MaxQueryLimit {
+		log.Printf("Warning: the query of %s returned the maximum of %d events, so some events are missing. Narrow the time range or the filter\n", source, cloudwatchclient.MaxQueryLimit)
+	}
+}
+
+func queryTarget(ctx context.Context, target awsTarget, runOption AppOption, query logQuery, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	ecsClient := ecsclient.NewEcsClient(ctx, &target.cfg)
+	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &target.cfg)
+
+	_, containerDef, err := selectTaskAndContainer(ecsClient, runOption)
+	if err != nil {
+		return nil, err
+	}
+
+	logGroup, logStreamPrefix, err := getLogConfiguration(containerDef)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetching logs from log group: %s, stream prefix: %s (region: %s)\n", logGroup, logStreamPrefix, target.cfg.Region)
+
+	results, err := logsClient.QueryLogs(logGroup, query.build(logStreamPrefix), startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %v", err)
+	}
+	warnQueryLimit(results, "log group "+logGroup)
+
+	if query.process != nil {
+		results = query.process(results)
+	}
+	return results, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/ecsclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/selector"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/traceid"
+)
+
+// maxTraceQueries is the number of queries trace runs at once, well below the Logs Insights
+// limit of concurrent queries per account
+const maxTraceQueries = 10
+
+// runTrace searches the logs of the containers of several task definition families for a request
+// or trace ID and prints the matching events of all of them in chronological order
+func runTrace(c *cli.Context) error {
+	ctx := context.Background()
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if !c.IsSet("fields") && c.String("format") != "template" && c.String("format") != "sqlite" {
+		// a merged view of several services is only readable with the time of each event
+		runOption.fields = []string{"@timestamp", "@message"}
+	}
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+	if err := runOption.validateFormat(); err != nil {
+		return err
+	}
+	if len(runOption.profiles) > 1 || len(runOption.regions) > 1 {
+		return fmt.Errorf("trace can only be used with a single profile and region")
+	}
+
+	if c.NArg() != 1 {
+		return fmt.Errorf("trace requires exactly one request or trace ID")
+	}
+	terms, err := traceid.Terms(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := setupAWSConfig(ctx, runOption, firstOrEmpty(runOption.profiles), firstOrEmpty(runOption.regions))
+	if err != nil {
+		return err
+	}
+	ecsClient := ecsclient.NewEcsClient(ctx, &cfg)
+	logsClient := cloudwatchclient.NewCloudWatchClient(ctx, &cfg)
+
+	families, err := selectTraceFamilies(ecsClient, c.StringSlice("families"))
+	if err != nil {
+		return err
+	}
+	sources, err := familySources(ecsClient, families, runOption.container)
+	if err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-runOption.duration)
+	log.Printf("Searching %d containers for: %s\n", len(sources), strings.Join(terms, ", "))
+	log.Printf("Time range: %s to %s\n", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+
+	results, err := queryTrace(logsClient, sources, runOption, terms, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		log.Println("No logs found for the ID in the specified time range")
+		return nil
+	}
+	return writeResults(results, runOption)
+}
+
+// selectTraceFamilies returns the given task definition families, or lets the user select them
+func selectTraceFamilies(ecsClient *ecsclient.EcsClient, names []string) ([]string, error) {
+	if len(names) > 0 {
+		return names, nil
+	}
+
+	families, err := ecsClient.ListTaskDefinitionFamilies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task definition families: %v", err)
+	}
+	if len(families) == 0 {
+		return nil, fmt.Errorf("no task definition families found")
+	}
+	selected, err := selector.SelectMultiple(families, "Select Task Definition Families > ")
+	if err != nil {
+		return nil, fmt.Errorf("task definition family selection aborted: %v", err)
+	}
+
+	names = make([]string, len(selected))
+	for i, family := range selected {
+		names[i] = family.Name
+	}
+	return names, nil
+}
+
+// familySources resolves the log configuration of the containers of the latest task definition of each family
+func familySources(ecsClient *ecsclient.EcsClient, families []string, container string) ([]logSource, error) {
+	var sources []logSource
+	for _, family := range families {
+		taskDef, err := ecsClient.DescribeLatestTaskDefinition(ecsclient.TaskDefFamily{Name: family})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe latest task definition of %s: %v", family, err)
+		}
+		sources = append(sources, containerSources(family, taskDef, container)...)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no containers with CloudWatch Logs configuration found")
+	}
+	return sources, nil
+}
+
+// queryTrace queries every source concurrently, maxTraceQueries at a time, for messages containing any of the terms.
+// The results are merged in timestamp order and labelled with service and container columns.
+func queryTrace(logsClient *cloudwatchclient.CloudWatchClient, sources []logSource, runOption AppOption, terms []string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	order, err := runOption.resultOrder()
+	if err != nil {
+		return nil, err
+	}
+	// the results of the services are merged by timestamp
+	queryFields, hiddenFields := cloudwatchclient.IncludeFields(runOption.fields, "@timestamp")
+
+	results := make([][][]cwTypes.ResultField, len(sources))
+	errs := make([]error, len(sources))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxTraceQueries)
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source logSource) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			query := cloudwatchclient.BuildCloudWatchQuery(source.logStreamPrefix, queryFields, runOption.filter) +
+				" | " + traceid.InsightsFilter(terms) + order.QueryCommands()
+			results[i], errs[i] = logsClient.QueryLogs(source.logGroup, query, startTime, endTime)
+		}(i, source)
+	}
+	wg.Wait()
+
+	var merged [][]cwTypes.ResultField
+	for i, source := range sources {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to query logs of container %s of %s: %v", source.container, source.service, errs[i])
+		}
+		log.Printf("Found %d events in container %s of %s\n", len(results[i]), source.container, source.service)
+		warnQueryLimit(results[i], fmt.Sprintf("container %s of %s", source.container, source.service))
+		cloudwatchclient.AddField(results[i], "service", source.service)
+		cloudwatchclient.AddField(results[i], "container", source.container)
+		merged = append(merged, results[i]...)
+	}
+
+	cloudwatchclient.SortByTimestamp(merged)
+	merged = order.Apply(merged)
+	cloudwatchclient.DropFields(merged, hiddenFields...)
+	return merged, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	appConfig "github.com/bonyuta0204/ecs-log-viewer/pkg/config"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/watch"
+)
+
+// ruleList collects the values of a repeated flag without splitting them at commas,
+// which patterns such as "/a,b/" may contain
+type ruleList []string
+
+func (l *ruleList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *ruleList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+// runWatch periodically queries the logs of the selected container and notifies
+// when the alert rules trigger, until interrupted
+func runWatch(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	runOption := newAppOption(c)
+	log.SetFlags(0)
+
+	if err := runOption.validate(); err != nil {
+		return err
+	}
+
+	cfg, err := appConfig.Load(runOption.configPath)
+	if err != nil {
+		return err
+	}
+	rules, err := watchRules(c, cfg)
+	if err != nil {
+		return err
+	}
+	notifiers := watchNotifiers(c, cfg)
+
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	redactor, err := newRedactor(runOption)
+	if err != nil {
+		return err
+	}
+
+	targets, err := setupAWSTargets(ctx, runOption)
+	if err != nil {
+		return err
+	}
+
+	runOption, _, err = selectContainer(ctx, targets, runOption)
+	if err != nil {
+		return err
+	}
+
+	watcher := watch.NewWatcher(rules, interval)
+	// only fetch the messages matching a rule; the rules are evaluated on them locally
+	filter := watch.InsightsFilter(rules)
+	query := logQuery{
+		build: func(logStreamPrefix string) string {
+			return cloudwatchclient.BuildCloudWatchQuery(logStreamPrefix, []string{"@timestamp", "@message"}, runOption.filter) +
+				" | " + filter + " | sort @timestamp desc | limit 10000"
+		},
+	}
+
+	log.Printf("Watching %s/%s every %s with %d rules. Press Ctrl-C to stop\n", runOption.taskdef, runOption.container, interval, len(rules))
+	for {
+		now := time.Now()
+		results, err := queryTargets(ctx, targets, runOption, query, now.Add(-watcher.Lookback()), now)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Warning: failed to query logs: %v\n", err)
+		} else {
+			// the rules see the original messages; only the samples shown in alerts are redacted
+			alerts := watcher.Evaluate(results, now)
+			if redactor != nil {
+				watch.RedactAlerts(alerts, redactor.Redact)
+			}
+			for _, alert := range alerts {
+				reportAlert(ctx, alert, notifiers)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watchRules parses the rules given with --rule and in the config file
+func watchRules(c *cli.Context, cfg *appConfig.Config) ([]watch.Rule, error) {
+	var exprs []string
+	if flagRules, ok := c.Generic("rule").(*ruleList); ok && flagRules != nil {
+		exprs = append(exprs, *flagRules...)
+	}
+	exprs = append(exprs, cfg.Watch.Rules...)
+	if len(exprs) == 0 {
+		return nil, fmt.Errorf("no rules to watch: specify --rule or watch.rules in the config file")
+	}
+
+	rules := make([]watch.Rule, len(exprs))
+	for i, expr := range exprs {
+		rule, err := watch.ParseRule(expr)
+		if err != nil {
+			return nil, err
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// watchNotifiers returns the notifiers given with flags and in the config file
+func watchNotifiers(c *cli.Context, cfg *appConfig.Config) []watch.Notifier {
+	var notifiers []watch.Notifier
+	if c.Bool("notify") || cfg.Watch.Notify {
+		notifiers = append(notifiers, watch.DesktopNotifier{})
+	}
+	for _, command := range []string{c.String("exec"), cfg.Watch.Exec} {
+		if command != "" {
+			notifiers = append(notifiers, watch.CommandNotifier{Command: command})
+		}
+	}
+	for _, url := range []string{c.String("webhook"), cfg.Watch.Webhook} {
+		if url != "" {
+			notifiers = append(notifiers, watch.WebhookNotifier{URL: url})
+		}
+	}
+	return notifiers
+}
+
+// reportAlert prints the alert to stdout and delivers it to every notifier
+func reportAlert(ctx context.Context, alert watch.Alert, notifiers []watch.Notifier) {
+	fmt.Printf("%s ALERT %s\n", alert.Time.Format(time.RFC3339), alert.Message())
+	if alert.Sample != "" {
+		fmt.Printf("  %s\n", strings.ReplaceAll(alert.Sample, "\n", " "))
+	}
+
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			log.Printf("Warning: failed to deliver alert: %v\n", err)
+		}
+	}
+}
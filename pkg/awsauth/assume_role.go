@@ -0,0 +1,60 @@
+package awsauth
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// DefaultSessionName is used when no role session name is specified
+const DefaultSessionName = "ecs-log-viewer"
+
+// AssumeRoleOption contains the parameters used to assume an IAM role
+type AssumeRoleOption struct {
+	RoleARN     string
+	ExternalID  string
+	MFASerial   string
+	SessionName string
+	Duration    time.Duration
+	// Profile is the source profile name. It is only used to scope the session cache.
+	Profile string
+}
+
+// WithAssumeRole returns a copy of cfg whose credentials are obtained by assuming the given role
+// with the original credentials of cfg. Assumed sessions are cached on disk under cacheDir
+// so that MFA is only requested when the cached session expires.
+// tokenProvider is called to read the MFA token code when MFASerial is set.
+func WithAssumeRole(cfg aws.Config, opt AssumeRoleOption, cacheDir string, tokenProvider func() (string, error)) aws.Config {
+	sessionName := opt.SessionName
+	if sessionName == "" {
+		sessionName = DefaultSessionName
+	}
+
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), opt.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if opt.Duration > 0 {
+			o.Duration = opt.Duration
+		}
+		if opt.ExternalID != "" {
+			o.ExternalID = aws.String(opt.ExternalID)
+		}
+		if opt.MFASerial != "" {
+			o.SerialNumber = aws.String(opt.MFASerial)
+			o.TokenProvider = tokenProvider
+		}
+	})
+
+	var credentials aws.CredentialsProvider = provider
+	if cacheDir != "" {
+		credentials = &fileCacheProvider{
+			provider: provider,
+			path:     cacheFilePath(cacheDir, opt),
+		}
+	}
+
+	assumed := cfg.Copy()
+	assumed.Credentials = aws.NewCredentialsCache(credentials)
+	return assumed
+}
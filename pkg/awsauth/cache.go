@@ -0,0 +1,120 @@
+package awsauth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// expiryWindow is how long before expiration a cached session is considered stale
+const expiryWindow = time.Minute
+
+// DefaultCacheDir returns the directory used to cache assumed role sessions
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ecs-log-viewer", "sts"), nil
+}
+
+// cachedCredentials is the on-disk representation of an assumed role session
+type cachedCredentials struct {
+	AccessKeyID     string    `json:"accessKeyId"`
+	SecretAccessKey string    `json:"secretAccessKey"`
+	SessionToken    string    `json:"sessionToken"`
+	Expires         time.Time `json:"expires"`
+}
+
+// fileCacheProvider wraps a credentials provider and persists its credentials to a file
+type fileCacheProvider struct {
+	provider aws.CredentialsProvider
+	path     string
+}
+
+// cacheFilePath returns the cache file for the given role option.
+// The file name is derived from every parameter that affects the resulting session.
+func cacheFilePath(cacheDir string, opt AssumeRoleOption) string {
+	key := strings.Join([]string{
+		opt.Profile,
+		opt.RoleARN,
+		opt.ExternalID,
+		opt.MFASerial,
+		opt.SessionName,
+		opt.Duration.String(),
+	}, "\x00")
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Retrieve returns the cached credentials if they are still valid, otherwise it
+// retrieves new credentials from the wrapped provider and stores them.
+func (p *fileCacheProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if creds, ok := p.load(); ok {
+		return creds, nil
+	}
+
+	creds, err := p.provider.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	if err := p.store(creds); err != nil {
+		log.Printf("Warning: failed to cache assumed role session: %v\n", err)
+	}
+	return creds, nil
+}
+
+func (p *fileCacheProvider) load() (aws.Credentials, bool) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return aws.Credentials{}, false
+	}
+
+	var cached cachedCredentials
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return aws.Credentials{}, false
+	}
+	if time.Now().Add(expiryWindow).After(cached.Expires) {
+		return aws.Credentials{}, false
+	}
+
+	return aws.Credentials{
+		AccessKeyID:     cached.AccessKeyID,
+		SecretAccessKey: cached.SecretAccessKey,
+		SessionToken:    cached.SessionToken,
+		Source:          "ecs-log-viewer session cache",
+		CanExpire:       true,
+		Expires:         cached.Expires,
+	}, true
+}
+
+func (p *fileCacheProvider) store(creds aws.Credentials) error {
+	if !creds.CanExpire {
+		return nil
+	}
+
+	data, err := json.Marshal(cachedCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expires:         creds.Expires,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return os.WriteFile(p.path, data, 0o600)
+}
@@ -0,0 +1,85 @@
+package awsauth
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// countingProvider returns fixed credentials and records how many times it was called.
+type countingProvider struct {
+	calls int
+	creds aws.Credentials
+}
+
+func (p *countingProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	p.calls++
+	return p.creds, nil
+}
+
+// TestFileCacheProvider_ReusesValidSession tests that a cached session is used instead of assuming the role again.
+func TestFileCacheProvider_ReusesValidSession(t *testing.T) {
+	inner := &countingProvider{creds: aws.Credentials{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+		SessionToken:    "TOKEN",
+		CanExpire:       true,
+		Expires:         time.Now().Add(time.Hour),
+	}}
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	first := &fileCacheProvider{provider: inner, path: path}
+	if _, err := first.Retrieve(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	second := &fileCacheProvider{provider: inner, path: path}
+	creds, err := second.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if inner.calls != 1 {
+		t.Errorf("Expected provider to be called once, got %d", inner.calls)
+	}
+	if creds.AccessKeyID != "AKID" || creds.SessionToken != "TOKEN" {
+		t.Errorf("Unexpected cached credentials: %+v", creds)
+	}
+}
+
+// TestFileCacheProvider_RefreshesExpiredSession tests that an expired cached session is not used.
+func TestFileCacheProvider_RefreshesExpiredSession(t *testing.T) {
+	inner := &countingProvider{creds: aws.Credentials{
+		AccessKeyID: "AKID",
+		CanExpire:   true,
+		Expires:     time.Now().Add(30 * time.Second),
+	}}
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	p := &fileCacheProvider{provider: inner, path: path}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Retrieve(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if inner.calls != 2 {
+		t.Errorf("Expected provider to be called twice, got %d", inner.calls)
+	}
+}
+
+// TestCacheFilePath tests that sessions for different roles are cached separately.
+func TestCacheFilePath(t *testing.T) {
+	prod := cacheFilePath("/cache", AssumeRoleOption{RoleARN: "arn:aws:iam::111111111111:role/viewer"})
+	staging := cacheFilePath("/cache", AssumeRoleOption{RoleARN: "arn:aws:iam::222222222222:role/viewer"})
+
+	if prod == staging {
+		t.Errorf("Expected different cache paths, got %q for both", prod)
+	}
+	if filepath.Dir(prod) != "/cache" {
+		t.Errorf("Expected cache path under /cache, got %q", prod)
+	}
+}
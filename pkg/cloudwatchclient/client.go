@@ -3,6 +3,7 @@ package cloudwatchclient
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -24,17 +25,36 @@ func NewCloudWatchClient(ctx context.Context, config *aws.Config) *CloudWatchCli
 	}
 }
 
+// MaxQueryLogGroups is the largest number of log groups a single Logs Insights query can search
+const MaxQueryLogGroups = 50
+
 // QueryLogs queries logs from streams matching the prefix within the specified time range
 func (c *CloudWatchClient) QueryLogs(logGroup, query string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
-
-	// Start the query
-	startQueryInput := &cw.StartQueryInput{
+	return c.runQuery(&cw.StartQueryInput{
 		LogGroupName: aws.String(logGroup),
 		StartTime:    aws.Int64(startTime.Unix()),
 		EndTime:      aws.Int64(endTime.Unix()),
 		QueryString:  aws.String(query),
+	})
+}
+
+// QueryLogGroups runs one query across up to MaxQueryLogGroups log groups within the specified time range.
+// The @log field of the results holds the log group of each event.
+func (c *CloudWatchClient) QueryLogGroups(logGroups []string, query string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	if len(logGroups) > MaxQueryLogGroups {
+		return nil, fmt.Errorf("too many log groups for one query: %d (at most %d)", len(logGroups), MaxQueryLogGroups)
 	}
+	return c.runQuery(&cw.StartQueryInput{
+		LogGroupNames: logGroups,
+		StartTime:     aws.Int64(startTime.Unix()),
+		EndTime:       aws.Int64(endTime.Unix()),
+		QueryString:   aws.String(query),
+	})
+}
 
+// runQuery starts a query and waits for its results
+func (c *CloudWatchClient) runQuery(startQueryInput *cw.StartQueryInput) ([][]cwTypes.ResultField, error) {
+	// Start the query
 	startQueryOutput, err := c.client.StartQuery(c.ctx, startQueryInput)
 	if err != nil {
 		return nil, err
@@ -67,3 +87,32 @@ func (c *CloudWatchClient) QueryLogs(logGroup, query string, startTime, endTime
 
 	return results, nil
 }
+
+// LogGroupField is a field discovered in a log group with the percentage of events containing it
+type LogGroupField struct {
+	Name    string
+	Percent int
+}
+
+// LogGroupFields returns the fields found in the log events of the last 15 minutes of a log group,
+// most frequent first
+func (c *CloudWatchClient) LogGroupFields(logGroup string) ([]LogGroupField, error) {
+	resp, err := c.client.GetLogGroupFields(c.ctx, &cw.GetLogGroupFieldsInput{
+		LogGroupName: aws.String(logGroup),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]LogGroupField, 0, len(resp.LogGroupFields))
+	for _, field := range resp.LogGroupFields {
+		fields = append(fields, LogGroupField{Name: aws.ToString(field.Name), Percent: int(field.Percent)})
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].Percent != fields[j].Percent {
+			return fields[i].Percent > fields[j].Percent
+		}
+		return fields[i].Name < fields[j].Name
+	})
+	return fields, nil
+}
@@ -0,0 +1,275 @@
+package cloudwatchclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ContextGroupField is the field numbering the groups of context lines, starting at 1
+const ContextGroupField = "group"
+
+// MaxContextMatches is the largest number of matches context lines are fetched for,
+// since each match needs its own GetLogEvents requests
+const MaxContextMatches = 200
+
+// contextSlack is the number of extra events fetched around a match to find it among
+// other events logged in the same millisecond
+const contextSlack = 10
+
+// ContextOption is the number of events of the same log stream shown around each match
+type ContextOption struct {
+	Before int
+	After  int
+}
+
+// Enabled reports whether context lines are fetched
+func (o ContextOption) Enabled() bool {
+	return o.Before > 0 || o.After > 0
+}
+
+// contextEvent is an event of a context group
+type contextEvent struct {
+	key   string
+	event []cwTypes.ResultField
+	match bool
+}
+
+// WithContext returns the matches of a query with the events logged before and after each of them
+// in the same log stream, like grep -B and -A. The matches need the @timestamp, @logStream and
+// @message fields. Matches whose context overlaps are merged into one group, and every event gets
+// the group number in the ContextGroupField field. Context events have the fields of the matches,
+// with only @timestamp, @logStream, @message and @ingestionTime set.
+func (c *CloudWatchClient) WithContext(logGroup string, matches [][]cwTypes.ResultField, opt ContextOption) ([][]cwTypes.ResultField, error) {
+	if len(matches) > MaxContextMatches {
+		return nil, fmt.Errorf("too many matches to fetch context lines for: %d (at most %d)", len(matches), MaxContextMatches)
+	}
+
+	sorted := make([][]cwTypes.ResultField, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, _ := FieldValue(sorted[i], "@logStream")
+		sj, _ := FieldValue(sorted[j], "@logStream")
+		if si != sj {
+			return si < sj
+		}
+		ti, _ := FieldValue(sorted[i], "@timestamp")
+		tj, _ := FieldValue(sorted[j], "@timestamp")
+		return ti < tj
+	})
+
+	var groups [][]contextEvent
+	for _, match := range sorted {
+		group, err := c.contextGroup(logGroup, match, opt)
+		if err != nil {
+			return nil, err
+		}
+		groups = appendContextGroup(groups, group)
+	}
+
+	// groups are shown in the order of their first event
+	sort.SliceStable(groups, func(i, j int) bool {
+		ti, _ := FieldValue(groups[i][0].event, "@timestamp")
+		tj, _ := FieldValue(groups[j][0].event, "@timestamp")
+		return ti < tj
+	})
+
+	var results [][]cwTypes.ResultField
+	for i, group := range groups {
+		for _, entry := range group {
+			event := append(entry.event[:len(entry.event):len(entry.event)], cwTypes.ResultField{
+				Field: aws.String(ContextGroupField),
+				Value: aws.String(strconv.Itoa(i + 1)),
+			})
+			results = append(results, event)
+		}
+	}
+	return results, nil
+}
+
+// contextGroup returns a match with the events around it
+func (c *CloudWatchClient) contextGroup(logGroup string, match []cwTypes.ResultField, opt ContextOption) ([]contextEvent, error) {
+	logStream, _ := FieldValue(match, "@logStream")
+	value, _ := FieldValue(match, "@timestamp")
+	message, _ := FieldValue(match, "@message")
+	timestamp, err := ParseTimestamp(value)
+	if err != nil || logStream == "" {
+		return []contextEvent{{event: match, match: true}}, nil
+	}
+	ms := timestamp.UnixMilli()
+	key := contextKey(logStream, ms, message)
+
+	var group []contextEvent
+	if opt.Before > 0 {
+		events, err := c.streamEvents(logGroup, logStream, nil, aws.Int64(ms+1), false, opt.Before+1+contextSlack)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, contextEvents(logStream, match, eventsBefore(events, logStream, ms, key, opt.Before))...)
+	}
+
+	group = append(group, contextEvent{key: key, event: match, match: true})
+
+	if opt.After > 0 {
+		events, err := c.streamEvents(logGroup, logStream, aws.Int64(ms), nil, true, opt.After+1+contextSlack)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, contextEvents(logStream, match, eventsAfter(events, logStream, ms, key, opt.After))...)
+	}
+	return group, nil
+}
+
+// eventsBefore returns the last n events before the match identified by key. When the match is not
+// among the events, e.g. because many events were logged in the same millisecond, the events before
+// its millisecond are used.
+func eventsBefore(events []cwTypes.OutputLogEvent, logStream string, ms int64, key string, n int) []cwTypes.OutputLogEvent {
+	end := -1
+	for i := len(events) - 1; i >= 0; i-- {
+		if contextKey(logStream, aws.ToInt64(events[i].Timestamp), aws.ToString(events[i].Message)) == key {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		end = 0
+		for end < len(events) && aws.ToInt64(events[end].Timestamp) < ms {
+			end++
+		}
+	}
+	return events[max(0, end-n):end]
+}
+
+// eventsAfter returns the first n events after the match identified by key. When the match is not
+// among the events, the events after its millisecond are used.
+func eventsAfter(events []cwTypes.OutputLogEvent, logStream string, ms int64, key string, n int) []cwTypes.OutputLogEvent {
+	start := -1
+	for i, event := range events {
+		if contextKey(logStream, aws.ToInt64(event.Timestamp), aws.ToString(event.Message)) == key {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		start = len(events)
+		for i, event := range events {
+			if aws.ToInt64(event.Timestamp) > ms {
+				start = i
+				break
+			}
+		}
+	}
+	return events[start:min(len(events), start+n)]
+}
+
+// appendContextGroup adds a group, merging it into the last group when they overlap
+func appendContextGroup(groups [][]contextEvent, group []contextEvent) [][]contextEvent {
+	if len(groups) == 0 {
+		return append(groups, group)
+	}
+	last := groups[len(groups)-1]
+
+	shown := make(map[string]int, len(last))
+	for i, entry := range last {
+		if entry.key != "" {
+			shown[entry.key] = i
+		}
+	}
+	overlap := -1
+	for i, entry := range group {
+		if _, ok := shown[entry.key]; ok {
+			overlap = i
+		}
+	}
+	if overlap < 0 {
+		return append(groups, group)
+	}
+
+	// a match shown as a context line of the previous group keeps all of its fields
+	for _, entry := range group[:overlap+1] {
+		if i, ok := shown[entry.key]; ok && entry.match {
+			last[i] = entry
+		}
+	}
+	groups[len(groups)-1] = append(last, group[overlap+1:]...)
+	return groups
+}
+
+// contextEvents converts stream events to events with the fields of the match
+func contextEvents(logStream string, match []cwTypes.ResultField, events []cwTypes.OutputLogEvent) []contextEvent {
+	entries := make([]contextEvent, 0, len(events))
+	for _, event := range events {
+		timestamp := aws.ToInt64(event.Timestamp)
+		values := map[string]string{
+			"@timestamp":     time.UnixMilli(timestamp).UTC().Format(TimestampLayout),
+			"@logStream":     logStream,
+			"@message":       aws.ToString(event.Message),
+			"@ingestionTime": time.UnixMilli(aws.ToInt64(event.IngestionTime)).UTC().Format(TimestampLayout),
+		}
+
+		fields := make([]cwTypes.ResultField, 0, len(match))
+		for _, field := range match {
+			name := aws.ToString(field.Field)
+			if name == "@ptr" {
+				continue
+			}
+			fields = append(fields, cwTypes.ResultField{Field: field.Field, Value: aws.String(values[name])})
+		}
+		entries = append(entries, contextEvent{
+			key:   contextKey(logStream, timestamp, aws.ToString(event.Message)),
+			event: fields,
+		})
+	}
+	return entries
+}
+
+func contextKey(logStream string, timestamp int64, message string) string {
+	return logStream + "\x00" + strconv.FormatInt(timestamp, 10) + "\x00" + message
+}
+
+// streamEvents returns up to limit events of a log stream in ascending order, from the start of the
+// time range when fromHead is set and from its end otherwise. Times are in milliseconds; the end is exclusive.
+func (c *CloudWatchClient) streamEvents(logGroup, logStream string, startTime, endTime *int64, fromHead bool, limit int) ([]cwTypes.OutputLogEvent, error) {
+	input := &cw.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+		StartTime:     startTime,
+		EndTime:       endTime,
+		StartFromHead: aws.Bool(fromHead),
+		Limit:         aws.Int32(int32(limit)),
+	}
+
+	var events []cwTypes.OutputLogEvent
+	for len(events) < limit {
+		resp, err := c.client.GetLogEvents(c.ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events of log stream %s: %v", logStream, err)
+		}
+		next := resp.NextBackwardToken
+		if fromHead {
+			events = append(events, resp.Events...)
+			next = resp.NextForwardToken
+		} else {
+			events = append(resp.Events, events...)
+		}
+		// the same token is returned at the end of the stream
+		if next == nil || aws.ToString(next) == aws.ToString(input.NextToken) {
+			break
+		}
+		input.NextToken = next
+	}
+
+	if len(events) > limit {
+		if fromHead {
+			events = events[:limit]
+		} else {
+			events = events[len(events)-limit:]
+		}
+	}
+	return events, nil
+}
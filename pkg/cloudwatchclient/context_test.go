@@ -0,0 +1,142 @@
+package cloudwatchclient
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func streamEvent(ms int64, message string) cwTypes.OutputLogEvent {
+	return cwTypes.OutputLogEvent{Timestamp: aws.Int64(ms), Message: aws.String(message), IngestionTime: aws.Int64(ms)}
+}
+
+func streamMessages(events []cwTypes.OutputLogEvent) []string {
+	var values []string
+	for _, event := range events {
+		values = append(values, aws.ToString(event.Message))
+	}
+	return values
+}
+
+func TestEventsBefore(t *testing.T) {
+	events := []cwTypes.OutputLogEvent{
+		streamEvent(1, "a"), streamEvent(2, "b"), streamEvent(3, "c"), streamEvent(3, "match"), streamEvent(3, "d"),
+	}
+	key := contextKey("s", 3, "match")
+
+	if got := streamMessages(eventsBefore(events, "s", 3, key, 2)); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("eventsBefore() = %v, want [b c]", got)
+	}
+	if got := streamMessages(eventsBefore(events, "s", 3, key, 10)); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("eventsBefore() = %v, want [a b c]", got)
+	}
+	// the events before the millisecond when the match is not found
+	if got := streamMessages(eventsBefore(events, "s", 3, contextKey("s", 3, "other"), 5)); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("eventsBefore() without the match = %v, want [a b]", got)
+	}
+}
+
+func TestEventsAfter(t *testing.T) {
+	events := []cwTypes.OutputLogEvent{
+		streamEvent(3, "c"), streamEvent(3, "match"), streamEvent(3, "d"), streamEvent(4, "e"), streamEvent(5, "f"),
+	}
+	key := contextKey("s", 3, "match")
+
+	if got := streamMessages(eventsAfter(events, "s", 3, key, 2)); !reflect.DeepEqual(got, []string{"d", "e"}) {
+		t.Errorf("eventsAfter() = %v, want [d e]", got)
+	}
+	if got := streamMessages(eventsAfter(events, "s", 3, contextKey("s", 3, "other"), 5)); !reflect.DeepEqual(got, []string{"e", "f"}) {
+		t.Errorf("eventsAfter() without the match = %v, want [e f]", got)
+	}
+	if got := eventsAfter(events, "s", 9, contextKey("s", 9, "late"), 2); len(got) != 0 {
+		t.Errorf("eventsAfter() past the end = %v, want none", streamMessages(got))
+	}
+}
+
+func TestContextEvents(t *testing.T) {
+	match := []cwTypes.ResultField{
+		{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")},
+		{Field: ptr("level"), Value: ptr("ERROR")},
+		{Field: ptr("@message"), Value: ptr("boom")},
+		{Field: ptr("@ptr"), Value: ptr("xyz")},
+	}
+	entries := contextEvents("app/web/1", match, []cwTypes.OutputLogEvent{streamEvent(1739699999500, "before")})
+	if len(entries) != 1 {
+		t.Fatalf("contextEvents() returned %d events, want 1", len(entries))
+	}
+
+	want := []cwTypes.ResultField{
+		{Field: ptr("@timestamp"), Value: ptr("2025-02-16 09:59:59.500")},
+		{Field: ptr("level"), Value: ptr("")},
+		{Field: ptr("@message"), Value: ptr("before")},
+	}
+	if !reflect.DeepEqual(entries[0].event, want) {
+		t.Errorf("contextEvents() = %v, want %v", eventMap(entries[0].event), eventMap(want))
+	}
+	if entries[0].key != contextKey("app/web/1", 1739699999500, "before") || entries[0].match {
+		t.Errorf("contextEvents() key = %q, match = %v", entries[0].key, entries[0].match)
+	}
+}
+
+func TestAppendContextGroup(t *testing.T) {
+	entry := func(key string, match bool) contextEvent {
+		return contextEvent{key: key, match: match, event: []cwTypes.ResultField{{Field: ptr("@message"), Value: ptr(key)}}}
+	}
+	keys := func(groups [][]contextEvent) [][]string {
+		var values [][]string
+		for _, group := range groups {
+			var keys []string
+			for _, e := range group {
+				if e.match {
+					keys = append(keys, e.key+"*")
+				} else {
+					keys = append(keys, e.key)
+				}
+			}
+			values = append(values, keys)
+		}
+		return values
+	}
+
+	var groups [][]contextEvent
+	groups = appendContextGroup(groups, []contextEvent{entry("a", false), entry("b", true), entry("c", false)})
+	// overlapping context is merged, and the second match keeps its fields
+	groups = appendContextGroup(groups, []contextEvent{entry("b", false), entry("c", true), entry("d", false)})
+	// disjoint context starts a new group
+	groups = appendContextGroup(groups, []contextEvent{entry("x", false), entry("y", true)})
+
+	want := [][]string{{"a", "b*", "c*", "d"}, {"x", "y*"}}
+	if got := keys(groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("appendContextGroup() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteLogEvents_Groups(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{{Field: ptr("@message"), Value: ptr("a")}, {Field: ptr(ContextGroupField), Value: ptr("1")}},
+		{{Field: ptr("@message"), Value: ptr("b")}, {Field: ptr(ContextGroupField), Value: ptr("1")}},
+		{{Field: ptr("@message"), Value: ptr("c")}, {Field: ptr(ContextGroupField), Value: ptr("2")}},
+	}
+	opts := WriteOptions{WriteHeader: true, GroupField: ContextGroupField}
+
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{formatSimple, "a\nb\n--\nc\n"},
+		{formatTable, "@message\na\nb\n--\nc\n"},
+		{formatCSV, "@message,group\na,1\nb,1\nc,2\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteLogEvents(&buf, events, tt.format, opts); err != nil {
+			t.Fatalf("WriteLogEvents(%s) error = %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("WriteLogEvents(%s) = %q, want %q", tt.format, buf.String(), tt.want)
+		}
+	}
+}
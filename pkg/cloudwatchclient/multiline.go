@@ -0,0 +1,97 @@
+package cloudwatchclient
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// MultilineMatcher reports whether a log line starts a new event.
+// Lines for which it returns false are continuations of the preceding event.
+type MultilineMatcher func(line string) bool
+
+// multilinePresets match continuation lines of common stack trace formats
+var multilinePresets = map[string]*regexp.Regexp{
+	// "\tat com.example.Foo.bar(Foo.java:10)", "Caused by: ...", "java.lang.IllegalStateException: ..."
+	"java": regexp.MustCompile(`^(\s|Caused by:|Suppressed:|\.\.\. \d+ more|[\w$]+(\.[\w$]+)+(Exception|Error|Throwable)\b)`),
+	// "Traceback (most recent call last):", "  File \"app.py\", line 1", "ValueError: ..."
+	"python": regexp.MustCompile(`^(\s|Traceback \(most recent call last\):|During handling of the above exception|The above exception was the direct cause|[\w.]+(Error|Exception|Warning|Interrupt|Exit)(:|$))`),
+	// "goroutine 1 [running]:", "main.main()", "\t/app/main.go:10 +0x1d", "exit status 2"
+	"go": regexp.MustCompile(`^(\s|goroutine \d+ \[|created by |\[signal |exit status \d+|[\w./*()\-]+\(.*\)$)`),
+}
+
+// NewMultilineMatcher creates a MultilineMatcher from a preset name (java, python, go)
+// or a regular expression matching the first line of an event.
+func NewMultilineMatcher(spec string) (MultilineMatcher, error) {
+	if continuation, ok := multilinePresets[spec]; ok {
+		return func(line string) bool {
+			return !continuation.MatchString(line)
+		}, nil
+	}
+
+	start, err := regexp.Compile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiline start pattern %q: %v", spec, err)
+	}
+	return start.MatchString, nil
+}
+
+// StitchMultiline merges continuation lines into the @message of the preceding event in the same @logStream.
+// Events are processed in timestamp order and the stitched events are returned in timestamp order.
+// Lines logged in the same millisecond are ordered by @ingestionTime when the events have it, and
+// otherwise keep the order they were given in.
+// A continuation line without a preceding event in its stream is kept as a separate event.
+func StitchMultiline(events [][]cwTypes.ResultField, isStart MultilineMatcher) [][]cwTypes.ResultField {
+	sorted := make([][]cwTypes.ResultField, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, _ := FieldValue(sorted[i], "@timestamp")
+		tj, _ := FieldValue(sorted[j], "@timestamp")
+		if ti != tj {
+			return ti < tj
+		}
+		ii, _ := FieldValue(sorted[i], "@ingestionTime")
+		ij, _ := FieldValue(sorted[j], "@ingestionTime")
+		return ii < ij
+	})
+
+	var stitched [][]cwTypes.ResultField
+	// index of the last event in stitched for each log stream
+	lastByStream := make(map[string]int)
+
+	for _, event := range sorted {
+		stream, _ := FieldValue(event, "@logStream")
+		message, hasMessage := FieldValue(event, "@message")
+
+		last, hasLast := lastByStream[stream]
+		if hasMessage && hasLast && !isStart(message) {
+			appendToMessage(stitched[last], message)
+			continue
+		}
+
+		stitched = append(stitched, cloneEvent(event))
+		lastByStream[stream] = len(stitched) - 1
+	}
+
+	return stitched
+}
+
+// appendToMessage appends a line to the @message field of the event
+func appendToMessage(event []cwTypes.ResultField, line string) {
+	for i, field := range event {
+		if field.Field != nil && *field.Field == "@message" {
+			event[i].Value = aws.String(aws.ToString(field.Value) + "\n" + line)
+			return
+		}
+	}
+}
+
+// cloneEvent returns a copy of the event so that stitching does not modify the input
+func cloneEvent(event []cwTypes.ResultField) []cwTypes.ResultField {
+	cloned := make([]cwTypes.ResultField, len(event))
+	copy(cloned, event)
+	return cloned
+}
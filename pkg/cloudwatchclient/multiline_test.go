@@ -0,0 +1,137 @@
+package cloudwatchclient
+
+import (
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// event is a helper to build a log event with timestamp, stream and message fields.
+func event(timestamp, stream, message string) []cwTypes.ResultField {
+	return []cwTypes.ResultField{
+		{Field: ptr("@timestamp"), Value: ptr(timestamp)},
+		{Field: ptr("@logStream"), Value: ptr(stream)},
+		{Field: ptr("@message"), Value: ptr(message)},
+	}
+}
+
+func messages(events [][]cwTypes.ResultField) []string {
+	var got []string
+	for _, e := range events {
+		message, _ := FieldValue(e, "@message")
+		got = append(got, message)
+	}
+	return got
+}
+
+func TestStitchMultiline(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		events [][]cwTypes.ResultField
+		want   []string
+	}{
+		{
+			name: "java stack trace",
+			spec: "java",
+			events: [][]cwTypes.ResultField{
+				event("2025-02-16 10:00:00.003", "app/web/1", "\tat com.example.App.main(App.java:5)"),
+				event("2025-02-16 10:00:00.001", "app/web/1", "ERROR request failed"),
+				event("2025-02-16 10:00:00.002", "app/web/1", "java.lang.IllegalStateException: boom"),
+				event("2025-02-16 10:00:00.004", "app/web/1", "INFO next request"),
+			},
+			want: []string{
+				"ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.App.main(App.java:5)",
+				"INFO next request",
+			},
+		},
+		{
+			name: "python traceback interleaved across streams",
+			spec: "python",
+			events: [][]cwTypes.ResultField{
+				event("2025-02-16 10:00:00.001", "app/web/1", "Traceback (most recent call last):"),
+				event("2025-02-16 10:00:00.002", "app/web/2", "handled request"),
+				event("2025-02-16 10:00:00.003", "app/web/1", "  File \"app.py\", line 1, in <module>"),
+				event("2025-02-16 10:00:00.004", "app/web/1", "ValueError: bad value"),
+			},
+			want: []string{
+				"Traceback (most recent call last):\n  File \"app.py\", line 1, in <module>\nValueError: bad value",
+				"handled request",
+			},
+		},
+		{
+			name: "go panic",
+			spec: "go",
+			events: [][]cwTypes.ResultField{
+				event("2025-02-16 10:00:00.001", "app/web/1", "panic: runtime error: index out of range"),
+				event("2025-02-16 10:00:00.002", "app/web/1", "goroutine 1 [running]:"),
+				event("2025-02-16 10:00:00.003", "app/web/1", "main.main()"),
+				event("2025-02-16 10:00:00.004", "app/web/1", "\t/app/main.go:10 +0x1d"),
+				event("2025-02-16 10:00:00.005", "app/web/1", "exit status 2"),
+			},
+			want: []string{
+				"panic: runtime error: index out of range\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:10 +0x1d\nexit status 2",
+			},
+		},
+		{
+			name: "custom start pattern",
+			spec: `^\d{4}-\d{2}-\d{2}`,
+			events: [][]cwTypes.ResultField{
+				event("2025-02-16 10:00:00.001", "app/web/1", "continuation without start"),
+				event("2025-02-16 10:00:00.002", "app/web/1", "2025-02-16 first"),
+				event("2025-02-16 10:00:00.003", "app/web/1", "detail"),
+			},
+			want: []string{
+				"continuation without start",
+				"2025-02-16 first\ndetail",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewMultilineMatcher(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := messages(StitchMultiline(tt.events, matcher))
+			if len(got) != len(tt.want) {
+				t.Fatalf("StitchMultiline() = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStitchMultiline_SameMillisecond(t *testing.T) {
+	withIngestion := func(e []cwTypes.ResultField, ingestionTime string) []cwTypes.ResultField {
+		return append(e, cwTypes.ResultField{Field: ptr("@ingestionTime"), Value: ptr(ingestionTime)})
+	}
+	// Insights returned the lines of the same millisecond in reverse order
+	events := [][]cwTypes.ResultField{
+		withIngestion(event("2025-02-16 10:00:00.001", "app/web/1", "\tat com.example.App.main(App.java:5)"), "2025-02-16 10:00:00.103"),
+		withIngestion(event("2025-02-16 10:00:00.001", "app/web/1", "java.lang.IllegalStateException: boom"), "2025-02-16 10:00:00.102"),
+		withIngestion(event("2025-02-16 10:00:00.001", "app/web/1", "ERROR request failed"), "2025-02-16 10:00:00.101"),
+	}
+	matcher, err := NewMultilineMatcher("java")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := messages(StitchMultiline(events, matcher))
+	want := "ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.App.main(App.java:5)"
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("StitchMultiline() = %q, want [%q]", got, want)
+	}
+}
+
+func TestNewMultilineMatcher_InvalidPattern(t *testing.T) {
+	if _, err := NewMultilineMatcher("("); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
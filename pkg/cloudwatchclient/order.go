@@ -0,0 +1,79 @@
+package cloudwatchclient
+
+import (
+	"fmt"
+	"slices"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// MaxQueryLimit is the largest number of results a Logs Insights query can return
+const MaxQueryLimit = 10000
+
+// SortOrder is the order of log events by @timestamp
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// ParseSortOrder parses asc or desc. An empty string keeps the order Insights returns.
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch SortOrder(s) {
+	case "", SortAsc, SortDesc:
+		return SortOrder(s), nil
+	default:
+		return "", fmt.Errorf("invalid sort order: %s (expected asc or desc)", s)
+	}
+}
+
+// ResultOrder selects which events of the time range are returned and in which order
+type ResultOrder struct {
+	// Sort is the order of the returned events. Empty keeps the order Insights returns.
+	Sort SortOrder
+	// Limit is the maximum number of events. Zero means no limit.
+	Limit int
+	// Newest keeps the newest events when limited, otherwise the oldest
+	Newest bool
+}
+
+// Enabled reports whether the order or number of events is changed
+func (o ResultOrder) Enabled() bool {
+	return o.Sort != "" || o.Limit > 0
+}
+
+// QueryCommands returns the Insights commands appended to a query, e.g. " | sort @timestamp desc | limit 100"
+func (o ResultOrder) QueryCommands() string {
+	switch {
+	case o.Limit > 0 && o.Newest:
+		return fmt.Sprintf(" | sort @timestamp desc | limit %d", o.Limit)
+	case o.Limit > 0:
+		return fmt.Sprintf(" | sort @timestamp asc | limit %d", o.Limit)
+	case o.Sort != "":
+		return fmt.Sprintf(" | sort @timestamp %s", o.Sort)
+	default:
+		return ""
+	}
+}
+
+// Apply limits and orders events, e.g. after the results of several queries have been merged.
+// The events need the @timestamp field.
+func (o ResultOrder) Apply(events [][]cwTypes.ResultField) [][]cwTypes.ResultField {
+	if !o.Enabled() {
+		return events
+	}
+
+	SortByTimestamp(events)
+	if o.Limit > 0 && len(events) > o.Limit {
+		if o.Newest {
+			events = events[len(events)-o.Limit:]
+		} else {
+			events = events[:o.Limit]
+		}
+	}
+	if o.Sort == SortDesc {
+		slices.Reverse(events)
+	}
+	return events
+}
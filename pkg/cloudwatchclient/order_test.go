@@ -0,0 +1,75 @@
+package cloudwatchclient
+
+import (
+	"reflect"
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func orderEvents(timestamps ...string) [][]cwTypes.ResultField {
+	var events [][]cwTypes.ResultField
+	for _, ts := range timestamps {
+		events = append(events, []cwTypes.ResultField{{Field: ptr("@timestamp"), Value: ptr(ts)}})
+	}
+	return events
+}
+
+func timestamps(events [][]cwTypes.ResultField) []string {
+	var values []string
+	for _, event := range events {
+		value, _ := FieldValue(event, "@timestamp")
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestResultOrder_QueryCommands(t *testing.T) {
+	tests := []struct {
+		order ResultOrder
+		want  string
+	}{
+		{ResultOrder{}, ""},
+		{ResultOrder{Sort: SortDesc}, " | sort @timestamp desc"},
+		{ResultOrder{Sort: SortAsc, Limit: 10}, " | sort @timestamp asc | limit 10"},
+		{ResultOrder{Sort: SortAsc, Limit: 10, Newest: true}, " | sort @timestamp desc | limit 10"},
+	}
+	for _, tt := range tests {
+		if got := tt.order.QueryCommands(); got != tt.want {
+			t.Errorf("%+v.QueryCommands() = %q, want %q", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestResultOrder_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		order ResultOrder
+		want  []string
+	}{
+		{"unchanged", ResultOrder{}, []string{"2", "1", "3"}},
+		{"sort desc", ResultOrder{Sort: SortDesc}, []string{"3", "2", "1"}},
+		{"head", ResultOrder{Sort: SortAsc, Limit: 2}, []string{"1", "2"}},
+		{"tail", ResultOrder{Sort: SortAsc, Limit: 2, Newest: true}, []string{"2", "3"}},
+		{"newest first", ResultOrder{Sort: SortDesc, Limit: 2, Newest: true}, []string{"3", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := timestamps(tt.order.Apply(orderEvents("2", "1", "3")))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	for _, s := range []string{"", "asc", "desc"} {
+		if _, err := ParseSortOrder(s); err != nil {
+			t.Errorf("ParseSortOrder(%q) error: %v", s, err)
+		}
+	}
+	if _, err := ParseSortOrder("newest"); err == nil {
+		t.Error("ParseSortOrder(\"newest\") expected an error")
+	}
+}
@@ -20,3 +20,43 @@ func BuildCloudWatchQuery(streamPrefix string, fields []string, filter string) s
 
 	return query
 }
+
+// BuildMultiStreamQuery constructs a CloudWatch Logs Insights query like BuildCloudWatchQuery that
+// selects the streams matching any of the prefixes, e.g. when searching the log groups of several containers
+func BuildMultiStreamQuery(streamPrefixes []string, fields []string, filter string) string {
+	conditions := make([]string, len(streamPrefixes))
+	for i, prefix := range streamPrefixes {
+		conditions[i] = fmt.Sprintf("@logStream like \"%s\"", prefix)
+	}
+	query := fmt.Sprintf("fields %s | filter %s", strings.Join(fields, ", "), strings.Join(conditions, " or "))
+
+	if filter != "" {
+		escapedFilter := strings.ReplaceAll(filter, "'", "\\'")
+		query += fmt.Sprintf(" | filter @message like '%s'", escapedFilter)
+	}
+	return query
+}
+
+// BuildStatsQuery constructs a CloudWatch Logs Insights query that runs the given commands
+// (e.g. "stats count(*) as lines by bin(1m)") on the messages of the stream prefix matching the filter
+func BuildStatsQuery(streamPrefix, filter, commands string) string {
+	query := fmt.Sprintf("filter @logStream like \"%s\"", streamPrefix)
+	if filter != "" {
+		escapedFilter := strings.ReplaceAll(filter, "'", "\\'")
+		query += fmt.Sprintf(" | filter @message like '%s'", escapedFilter)
+	}
+	return query + " | " + strings.TrimSpace(commands)
+}
+
+// IncludeFields returns fields with the required fields appended when they are missing.
+// The appended fields are also returned separately so that they can be dropped from the results later.
+func IncludeFields(fields []string, required ...string) (all []string, added []string) {
+	all = append(all, fields...)
+	for _, name := range required {
+		if !containsString(all, name) {
+			all = append(all, name)
+			added = append(added, name)
+		}
+	}
+	return all, added
+}
@@ -1,6 +1,9 @@
 package cloudwatchclient
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func Test_BuildCloudWatchQuery(t *testing.T) {
 	tests := []struct {
@@ -49,3 +52,30 @@ func Test_BuildCloudWatchQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestIncludeFields(t *testing.T) {
+	all, added := IncludeFields([]string{"@message", "@timestamp"}, "@timestamp", "@logStream")
+
+	if strings.Join(all, ",") != "@message,@timestamp,@logStream" {
+		t.Errorf("IncludeFields() all = %v", all)
+	}
+	if strings.Join(added, ",") != "@logStream" {
+		t.Errorf("IncludeFields() added = %v", added)
+	}
+}
+
+func TestBuildStatsQuery(t *testing.T) {
+	got := BuildStatsQuery("ecs/app", "can't", " stats count(*) as lines by level ")
+	want := "filter @logStream like \"ecs/app\" | filter @message like 'can\\'t' | stats count(*) as lines by level"
+	if got != want {
+		t.Errorf("BuildStatsQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildMultiStreamQuery(t *testing.T) {
+	got := BuildMultiStreamQuery([]string{"ecs/web", "ecs/worker"}, []string{"@timestamp", "@message"}, "can't")
+	want := "fields @timestamp, @message | filter @logStream like \"ecs/web\" or @logStream like \"ecs/worker\" | filter @message like 'can\\'t'"
+	if got != want {
+		t.Errorf("BuildMultiStreamQuery() = %q, want %q", got, want)
+	}
+}
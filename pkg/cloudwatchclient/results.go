@@ -0,0 +1,86 @@
+package cloudwatchclient
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TimestampLayout is the layout of @timestamp values returned by Logs Insights (always in UTC)
+const TimestampLayout = "2006-01-02 15:04:05.000"
+
+// ParseTimestamp parses a @timestamp value returned by Logs Insights
+func ParseTimestamp(value string) (time.Time, error) {
+	return time.Parse(TimestampLayout, value)
+}
+
+// LogGroupName returns the log group name of a @log value, which is prefixed with the account ID
+// as in 123456789012:/ecs/app
+func LogGroupName(value string) string {
+	if _, name, ok := strings.Cut(value, ":"); ok {
+		return name
+	}
+	return value
+}
+
+// FieldValue returns the value of the named field in a log event
+func FieldValue(event []cwTypes.ResultField, name string) (string, bool) {
+	for _, field := range event {
+		if field.Field != nil && *field.Field == name {
+			if field.Value == nil {
+				return "", true
+			}
+			return *field.Value, true
+		}
+	}
+	return "", false
+}
+
+// AddField appends a field with a constant value to every log event
+func AddField(events [][]cwTypes.ResultField, name, value string) {
+	for i := range events {
+		events[i] = append(events[i], cwTypes.ResultField{
+			Field: aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+}
+
+// SortByTimestamp sorts log events by their @timestamp field in ascending order.
+// Insights timestamps have a fixed width, so they are compared as strings.
+// Events without a timestamp keep their relative order and are placed first.
+func SortByTimestamp(events [][]cwTypes.ResultField) {
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, _ := FieldValue(events[i], "@timestamp")
+		tj, _ := FieldValue(events[j], "@timestamp")
+		return ti < tj
+	})
+}
+
+// DropFields removes the named fields from every log event
+func DropFields(events [][]cwTypes.ResultField, names ...string) {
+	if len(names) == 0 {
+		return
+	}
+	for i, event := range events {
+		kept := event[:0]
+		for _, field := range event {
+			if !containsString(names, aws.ToString(field.Field)) {
+				kept = append(kept, field)
+			}
+		}
+		events[i] = kept
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package cloudwatchclient
+
+import (
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TestAddField tests that the field is appended to every event.
+func TestAddField(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{{Field: ptr("@message"), Value: ptr("first")}},
+		{{Field: ptr("@message"), Value: ptr("second")}},
+	}
+
+	AddField(events, "region", "ap-northeast-1")
+
+	for i, event := range events {
+		value, ok := FieldValue(event, "region")
+		if !ok || value != "ap-northeast-1" {
+			t.Errorf("event %d: expected region ap-northeast-1, got %q (found=%v)", i, value, ok)
+		}
+	}
+}
+
+// TestSortByTimestamp tests that merged events are ordered chronologically.
+func TestSortByTimestamp(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:02.000")}, {Field: ptr("@message"), Value: ptr("c")}},
+		{{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")}, {Field: ptr("@message"), Value: ptr("a")}},
+		{{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:01.000")}, {Field: ptr("@message"), Value: ptr("b")}},
+	}
+
+	SortByTimestamp(events)
+
+	var got string
+	for _, event := range events {
+		message, _ := FieldValue(event, "@message")
+		got += message
+	}
+	if got != "abc" {
+		t.Errorf("Expected order abc, got %s", got)
+	}
+}
+
+func TestLogGroupName(t *testing.T) {
+	tests := map[string]string{
+		"123456789012:/ecs/app": "/ecs/app",
+		"/ecs/app":              "/ecs/app",
+	}
+	for value, want := range tests {
+		if got := LogGroupName(value); got != want {
+			t.Errorf("LogGroupName(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
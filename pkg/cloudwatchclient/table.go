@@ -0,0 +1,149 @@
+package cloudwatchclient
+
+import (
+	"io"
+	"strings"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	// tableColumnGap is the space between table columns
+	tableColumnGap = "  "
+	// maxLeadingColumnWidth caps the width of all but the last column when truncating
+	maxLeadingColumnWidth = 40
+	// minLastColumnWidth is the minimum width left for the last column when truncating
+	minLastColumnWidth = 20
+)
+
+// WriteLogEventsTable writes CloudWatch log events as a table with aligned columns.
+// When width is positive, the columns are truncated so that each line fits within width;
+// the last column (usually the message) gets the remaining space.
+func WriteLogEventsTable(w io.Writer, events [][]cwTypes.ResultField, writeHeader bool, width int) error {
+	return writeTableGroups(w, [][][]cwTypes.ResultField{events}, writeHeader, width)
+}
+
+// writeTableGroups writes groups of log events as one table, with a separator line between the groups
+func writeTableGroups(w io.Writer, groups [][][]cwTypes.ResultField, writeHeader bool, width int) error {
+	var events [][]cwTypes.ResultField
+	// index of the first row of each group but the first
+	starts := make(map[int]bool)
+	for i, group := range groups {
+		if i > 0 {
+			starts[len(events)] = true
+		}
+		events = append(events, group...)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	headers, rows := tabulateEvents(events)
+	for _, row := range rows {
+		for i, value := range row {
+			row[i] = SingleLine(value)
+		}
+	}
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = uniseg.StringWidth(header)
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			if n := uniseg.StringWidth(value); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if width > 0 {
+		fitColumns(widths, width)
+	}
+
+	if writeHeader {
+		if err := writeTableRow(w, headers, widths); err != nil {
+			return err
+		}
+	}
+	for i, row := range rows {
+		if starts[i] {
+			if _, err := io.WriteString(w, groupSeparator+"\n"); err != nil {
+				return err
+			}
+		}
+		if err := writeTableRow(w, row, widths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fitColumns shrinks the column widths so that a line fits within width
+func fitColumns(widths []int, width int) {
+	last := len(widths) - 1
+	used := 0
+	for i := 0; i < last; i++ {
+		if widths[i] > maxLeadingColumnWidth {
+			widths[i] = maxLeadingColumnWidth
+		}
+		used += widths[i] + len(tableColumnGap)
+	}
+
+	remaining := width - used
+	if remaining < minLastColumnWidth {
+		remaining = minLastColumnWidth
+	}
+	if widths[last] > remaining {
+		widths[last] = remaining
+	}
+}
+
+func writeTableRow(w io.Writer, values []string, widths []int) error {
+	var sb strings.Builder
+	for i, value := range values {
+		value = truncateWidth(value, widths[i])
+		sb.WriteString(value)
+		if i < len(values)-1 {
+			sb.WriteString(strings.Repeat(" ", widths[i]-uniseg.StringWidth(value)))
+			sb.WriteString(tableColumnGap)
+		}
+	}
+	sb.WriteString("\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// SingleLine keeps a value on one line of aligned output: newlines are shown as ⏎, tabs become
+// spaces and carriage returns are dropped
+func SingleLine(value string) string {
+	value = strings.ReplaceAll(value, "\t", " ")
+	value = strings.ReplaceAll(value, "\r", "")
+	return strings.ReplaceAll(value, "\n", "⏎")
+}
+
+// truncateWidth shortens s to at most width terminal columns, marking truncation with an ellipsis.
+// Wide characters such as CJK take two columns.
+func truncateWidth(s string, width int) string {
+	if uniseg.StringWidth(s) <= width {
+		return s
+	}
+
+	var sb strings.Builder
+	used := 0
+	graphemes := uniseg.NewGraphemes(s)
+	for graphemes.Next() {
+		cluster := graphemes.Str()
+		n := uniseg.StringWidth(cluster)
+		// reserve one column for the ellipsis
+		if used+n > width-1 {
+			break
+		}
+		sb.WriteString(cluster)
+		used += n
+	}
+	if width > 0 {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
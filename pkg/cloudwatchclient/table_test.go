@@ -0,0 +1,59 @@
+package cloudwatchclient
+
+import (
+	"bytes"
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func tableEvents() [][]cwTypes.ResultField {
+	return [][]cwTypes.ResultField{
+		{
+			{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")},
+			{Field: ptr("@message"), Value: ptr("short")},
+			{Field: ptr("@ptr"), Value: ptr("ptr")},
+		},
+		{
+			{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:01.000")},
+			{Field: ptr("@message"), Value: ptr("a much longer message\nwith a second line")},
+			{Field: ptr("@ptr"), Value: ptr("ptr")},
+		},
+	}
+}
+
+// TestWriteLogEventsTable tests that columns are aligned and not truncated without a width.
+func TestWriteLogEventsTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLogEventsTable(&buf, tableEvents(), true, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "@timestamp               @message\n" +
+		"2025-02-16 10:00:00.000  short\n" +
+		"2025-02-16 10:00:01.000  a much longer message⏎with a second line\n"
+	if buf.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, buf.String())
+	}
+}
+
+// TestWriteLogEventsTable_Truncate tests that the last column is truncated to fit the width.
+func TestWriteLogEventsTable_Truncate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLogEventsTable(&buf, tableEvents(), false, 50); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "2025-02-16 10:00:00.000  short\n" +
+		"2025-02-16 10:00:01.000  a much longer message⏎wi…\n"
+	if buf.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, buf.String())
+	}
+}
+
+// TestTruncateWidth tests truncation of wide characters by terminal columns.
+func TestTruncateWidth(t *testing.T) {
+	if got := truncateWidth("エラーが発生しました", 7); got != "エラー…" {
+		t.Errorf("truncateWidth() = %q, want %q", got, "エラー…")
+	}
+}
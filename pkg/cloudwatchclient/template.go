@@ -0,0 +1,208 @@
+package cloudwatchclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/loglevel"
+)
+
+// ansiColors maps color names usable in templates to ANSI escape codes
+var ansiColors = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+	"bold":    "1",
+}
+
+// levelColors are the colors used by the levelcolor template function
+var levelColors = map[loglevel.Level]string{
+	loglevel.Error: "red",
+	loglevel.Warn:  "yellow",
+	loglevel.Info:  "green",
+	loglevel.Debug: "gray",
+}
+
+// templateFuncs are the helper functions available in output templates besides the time helpers of NewTemplate
+var templateFuncs = template.FuncMap{
+	// short returns the last segment of a slash-separated value, such as the task ID of a
+	// log stream, shortened to 8 characters
+	"short": func(s string) string {
+		if i := strings.LastIndex(s, "/"); i >= 0 {
+			s = s[i+1:]
+		}
+		return truncateRunes(s, 8, "")
+	},
+	// trunc shortens s to at most n characters, marking truncation with an ellipsis
+	"trunc": func(n int, s string) string {
+		return truncateRunes(s, n, "…")
+	},
+	// json returns the value at a dot-separated path in a JSON message, or an empty string
+	"json": jsonPath,
+	// color wraps s in ANSI escape codes for the named color
+	"color": func(name, s string) string {
+		code, ok := ansiColors[name]
+		if !ok {
+			return s
+		}
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	},
+	// levelcolor colors s by the log level detected in it
+	"levelcolor": func(s string) string {
+		code, ok := ansiColors[levelColors[loglevel.Detect(s)]]
+		if !ok {
+			return s
+		}
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NewTemplate parses an output template for the template format.
+// Fields are accessed by name without the leading @ (e.g. {{.message}}) or with index (e.g. {{index . "@message"}}).
+// Timestamps are converted with the time format given to WriteLogEventsTemplate, and the time helpers
+// format the original timestamps:
+//   - time formats a timestamp with a layout accepted by ParseTimeFormat in the time zone of timeFormat, local time by default
+//   - timeformat formats a timestamp with timeFormat itself
+func NewTemplate(text string, timeFormat TimeFormat) (*template.Template, error) {
+	funcs := make(template.FuncMap, len(templateFuncs)+2)
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	for name, fn := range timeFuncs(timeFormat, nil) {
+		funcs[name] = fn
+	}
+
+	tmpl, err := template.New("output").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// timeFuncs returns the time helpers of templates. originals maps the converted timestamps of the
+// event being rendered back to the timestamps Insights returned, so that the helpers can format them.
+func timeFuncs(timeFormat TimeFormat, originals map[string]string) template.FuncMap {
+	location, now := timeFormat.Location, timeFormat.Now
+	if location == nil {
+		location = time.Local
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	original := func(s string) string {
+		if value, ok := originals[s]; ok {
+			return value
+		}
+		return s
+	}
+
+	return template.FuncMap{
+		"time": func(layout, s string) string {
+			return TimeFormat{Layout: layout, Location: location, Now: now}.Format(original(s))
+		},
+		"timeformat": func(s string) string {
+			return timeFormat.Format(original(s))
+		},
+	}
+}
+
+// WriteLogEventsTemplate writes each CloudWatch log event rendered with the template on its own line.
+// Timestamps are converted with timeFormat, which should be the one the template was created with.
+// The time helpers of the template are rebound for each event so that they get the original timestamps.
+func WriteLogEventsTemplate(w io.Writer, events [][]cwTypes.ResultField, tmpl *template.Template, timeFormat TimeFormat) error {
+	if tmpl == nil {
+		return fmt.Errorf("template format requires a template")
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		data := make(map[string]string, len(event)*2)
+		originals := make(map[string]string)
+		for _, field := range event {
+			if *field.Field == "@ptr" {
+				continue
+			}
+			value := ""
+			if field.Value != nil {
+				value = *field.Value
+			}
+			if containsString(timeFields, *field.Field) {
+				if formatted := timeFormat.Format(value); formatted != value {
+					originals[formatted] = value
+					value = formatted
+				}
+			}
+			data[*field.Field] = value
+			data[strings.TrimPrefix(*field.Field, "@")] = value
+		}
+
+		tmpl.Funcs(timeFuncs(timeFormat, originals))
+		buf.Reset()
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonPath returns the value at a dot-separated path in a JSON document
+func jsonPath(path, document string) string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+}
+
+// truncateRunes shortens s to at most n characters including the marker
+func truncateRunes(s string, n int, marker string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	keep := n - utf8.RuneCountInString(marker)
+	if keep < 0 {
+		keep = 0
+	}
+	return string([]rune(s)[:keep]) + marker
+}
@@ -0,0 +1,92 @@
+package cloudwatchclient
+
+import (
+	"bytes"
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestWriteLogEventsTemplate(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{
+			{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")},
+			{Field: ptr("@logStream"), Value: ptr("ecs/app/5e7d0a3c9f1b4e2a8c6d")},
+			{Field: ptr("@message"), Value: ptr(`{"level":"error","req":{"id":"r-1"},"msg":"request failed with a long message"}`)},
+			{Field: ptr("@ptr"), Value: ptr("ptr")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "fields and short",
+			template: `[{{.logStream | short}}] {{index . "@timestamp"}}`,
+			want:     "[5e7d0a3c] 2025-02-16 10:00:00.000\n",
+		},
+		{
+			name:     "json access and truncation",
+			template: `{{.message | json "level" | upper}} {{.message | json "req.id"}} {{.message | json "msg" | trunc 10}}`,
+			want:     "ERROR r-1 request f…\n",
+		},
+		{
+			name:     "color",
+			template: `{{color "red" "x"}}`,
+			want:     "\x1b[31mx\x1b[0m\n",
+		},
+		{
+			name:     "missing fields are empty",
+			template: `<{{.missing}}>`,
+			want:     "<>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewTemplate(tt.template, TimeFormat{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := WriteLogEventsTemplate(&buf, events, tmpl, TimeFormat{}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Expected output %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestNewTemplate_Invalid(t *testing.T) {
+	if _, err := NewTemplate("{{.message", TimeFormat{}); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+}
+
+func TestWriteLogEventsTemplate_TimeFormat(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")}},
+	}
+	timeFormat, err := ParseTimeFormat("unixms", "Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := NewTemplate(`{{.timestamp}} {{.timestamp | time "15:04"}} {{.timestamp | time "rfc3339"}} {{.timestamp | timeformat}}`, timeFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLogEventsTemplate(&buf, events, tmpl, timeFormat); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "1739700000000 19:00 2025-02-16T19:00:00.000+09:00 1739700000000\n"; buf.String() != want {
+		t.Errorf("Expected output %q, got %q", want, buf.String())
+	}
+}
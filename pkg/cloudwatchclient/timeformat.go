@@ -0,0 +1,131 @@
+package cloudwatchclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Named time formats accepted by ParseTimeFormat
+const (
+	TimeFormatRFC3339  = "rfc3339"
+	TimeFormatUnix     = "unix"
+	TimeFormatUnixMs   = "unixms"
+	TimeFormatRelative = "relative"
+)
+
+// rfc3339Millis is the layout of the rfc3339 time format, keeping the milliseconds of Insights timestamps
+const rfc3339Millis = "2006-01-02T15:04:05.000Z07:00"
+
+// timeFields are the fields holding Insights timestamps
+var timeFields = []string{"@timestamp", "@ingestionTime"}
+
+// TimeFormat converts the timestamps of log events for output.
+// The zero value keeps the timestamps as Insights returns them.
+type TimeFormat struct {
+	// Layout is rfc3339, unix, unixms, relative or a Go time layout
+	Layout string
+	// Location is the time zone timestamps are shown in
+	Location *time.Location
+	// Now is the reference time of the relative format
+	Now time.Time
+}
+
+// ParseTimeFormat parses a time format and a time zone (local, UTC or an IANA name such as Asia/Tokyo).
+// Either can be empty: the format defaults to rfc3339 and the zone to local time.
+func ParseTimeFormat(format, tz string) (TimeFormat, error) {
+	if format == "" && tz == "" {
+		return TimeFormat{}, nil
+	}
+
+	var location *time.Location
+	switch strings.ToLower(tz) {
+	case "", "local":
+		location = time.Local
+	case "utc":
+		location = time.UTC
+	default:
+		var err error
+		if location, err = time.LoadLocation(tz); err != nil {
+			return TimeFormat{}, fmt.Errorf("invalid time zone: %s", tz)
+		}
+	}
+
+	switch format {
+	case "":
+		format = TimeFormatRFC3339
+	case TimeFormatRFC3339, TimeFormatUnix, TimeFormatUnixMs, TimeFormatRelative:
+	default:
+		// a layout without any element formats every time as itself
+		if time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC).Format(format) == format {
+			return TimeFormat{}, fmt.Errorf("invalid time format: %s (expected rfc3339, unix, unixms, relative or a Go time layout)", format)
+		}
+	}
+
+	return TimeFormat{Layout: format, Location: location, Now: time.Now()}, nil
+}
+
+// Enabled reports whether timestamps are converted
+func (f TimeFormat) Enabled() bool {
+	return f.Layout != ""
+}
+
+// Format converts an Insights timestamp. Values that are not timestamps are returned unchanged.
+func (f TimeFormat) Format(value string) string {
+	if !f.Enabled() {
+		return value
+	}
+	t, err := ParseTimestamp(value)
+	if err != nil {
+		return value
+	}
+
+	switch f.Layout {
+	case TimeFormatRFC3339:
+		return t.In(f.Location).Format(rfc3339Millis)
+	case TimeFormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeFormatUnixMs:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case TimeFormatRelative:
+		return relativeTime(t, f.Now)
+	default:
+		return t.In(f.Location).Format(f.Layout)
+	}
+}
+
+// relativeTime describes t relative to now, e.g. "1h2m3s ago"
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t).Round(time.Second)
+	switch {
+	case d == 0:
+		return "now"
+	case d < 0:
+		return "in " + (-d).String()
+	default:
+		return d.String() + " ago"
+	}
+}
+
+// FormatTimes returns a copy of the events with their timestamp fields converted
+func (f TimeFormat) FormatTimes(events [][]cwTypes.ResultField) [][]cwTypes.ResultField {
+	if !f.Enabled() {
+		return events
+	}
+
+	formatted := make([][]cwTypes.ResultField, len(events))
+	for i, event := range events {
+		formatted[i] = make([]cwTypes.ResultField, len(event))
+		for j, field := range event {
+			if field.Value != nil && containsString(timeFields, aws.ToString(field.Field)) {
+				field.Value = aws.String(f.Format(*field.Value))
+			}
+			formatted[i][j] = field
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,95 @@
+package cloudwatchclient
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestParseTimeFormat(t *testing.T) {
+	tests := []struct {
+		format, tz string
+		wantLayout string
+		wantZone   string
+		wantErr    bool
+	}{
+		{"", "", "", "", false},
+		{"", "UTC", TimeFormatRFC3339, "UTC", false},
+		{"unix", "", TimeFormatUnix, "Local", false},
+		{"15:04:05", "Asia/Tokyo", "15:04:05", "Asia/Tokyo", false},
+		{"relative", "local", TimeFormatRelative, "Local", false},
+		{"seconds", "", "", "", true},
+		{"", "Mars/Olympus", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeFormat(tt.format, tt.tz)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimeFormat(%q, %q) error = %v, wantErr %v", tt.format, tt.tz, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Layout != tt.wantLayout {
+			t.Errorf("ParseTimeFormat(%q, %q).Layout = %q, want %q", tt.format, tt.tz, got.Layout, tt.wantLayout)
+		}
+		if got.Location != nil && got.Location.String() != tt.wantZone {
+			t.Errorf("ParseTimeFormat(%q, %q).Location = %s, want %s", tt.format, tt.tz, got.Location, tt.wantZone)
+		}
+	}
+}
+
+func TestTimeFormat_Format(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	now := time.Date(2025, 2, 16, 10, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		format TimeFormat
+		value  string
+		want   string
+	}{
+		{TimeFormat{}, "2025-02-16 10:00:00.123", "2025-02-16 10:00:00.123"},
+		{TimeFormat{Layout: TimeFormatRFC3339, Location: time.UTC}, "2025-02-16 10:00:00.123", "2025-02-16T10:00:00.123Z"},
+		{TimeFormat{Layout: TimeFormatRFC3339, Location: tokyo}, "2025-02-16 20:00:00.123", "2025-02-17T05:00:00.123+09:00"},
+		{TimeFormat{Layout: TimeFormatUnix, Location: tokyo}, "2025-02-16 10:00:00.123", "1739700000"},
+		{TimeFormat{Layout: TimeFormatUnixMs, Location: tokyo}, "2025-02-16 10:00:00.123", "1739700000123"},
+		{TimeFormat{Layout: TimeFormatRelative, Now: now}, "2025-02-16 10:00:00.123", "5m0s ago"},
+		{TimeFormat{Layout: TimeFormatRelative, Now: now}, "2025-02-16 10:05:00.000", "now"},
+		{TimeFormat{Layout: "Jan 2 15:04 MST", Location: tokyo}, "2025-02-16 10:00:00.000", "Feb 16 19:00 JST"},
+		{TimeFormat{Layout: TimeFormatUnix, Location: time.UTC}, "not a time", "not a time"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.Format(tt.value); got != tt.want {
+			t.Errorf("%+v.Format(%q) = %q, want %q", tt.format.Layout, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLogEvents_TimeFormat(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{
+			{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")},
+			{Field: ptr("@message"), Value: ptr("2025-02-16 10:00:00.000")},
+		},
+	}
+	opts := WriteOptions{WriteHeader: true, Time: TimeFormat{Layout: TimeFormatUnix, Location: time.UTC}}
+
+	var buf bytes.Buffer
+	if err := WriteLogEvents(&buf, events, formatCSV, opts); err != nil {
+		t.Fatalf("WriteLogEvents() error = %v", err)
+	}
+	want := "@timestamp,@message\n1739700000,2025-02-16 10:00:00.000\n"
+	if buf.String() != want {
+		t.Errorf("WriteLogEvents() = %q, want %q", buf.String(), want)
+	}
+
+	// the events are not modified
+	if value, _ := FieldValue(events[0], "@timestamp"); value != "2025-02-16 10:00:00.000" {
+		t.Errorf("@timestamp of the events changed to %q", value)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"text/template"
 
 	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 )
@@ -13,29 +14,114 @@ import (
 type OutputFormat string
 
 const (
-	formatSimple OutputFormat = "simple"
-	formatCSV    OutputFormat = "csv"
-	formatJSON   OutputFormat = "json"
+	formatSimple   OutputFormat = "simple"
+	formatCSV      OutputFormat = "csv"
+	formatJSON     OutputFormat = "json"
+	formatTemplate OutputFormat = "template"
+	formatTable    OutputFormat = "table"
+	formatJSONL    OutputFormat = "jsonl"
 )
 
+// WriteOptions contains options for WriteLogEvents
+type WriteOptions struct {
+	// WriteHeader writes a header row in formats that have one
+	WriteHeader bool
+	// Template renders each event in the template format. See NewTemplate.
+	Template *template.Template
+	// Width is the maximum line width of the table format. Long values are truncated to fit.
+	// Zero disables truncation.
+	Width int
+	// Time converts timestamps. The time helpers of the template format get the original timestamps
+	// (see NewTemplate).
+	// The zero value keeps the timestamps as Insights returns them.
+	Time TimeFormat
+	// GroupField names a field numbering groups of consecutive events, such as context lines around
+	// matches. The simple, table and template formats print a separator line between the groups
+	// instead of the field; the other formats keep it as a column.
+	GroupField string
+}
+
+// groupSeparator is the line printed between groups of events, as grep does
+const groupSeparator = "--"
+
 // WriteLogEvents writes CloudWatch log events in the specified format
-func WriteLogEvents(w io.Writer, events [][]cwTypes.ResultField, format OutputFormat, writeHeader bool) error {
+func WriteLogEvents(w io.Writer, events [][]cwTypes.ResultField, format OutputFormat, opts WriteOptions) error {
 	if len(events) == 0 {
 		return nil
 	}
+	if format != formatTemplate {
+		// the template format converts timestamps itself to keep the originals for its time helpers
+		events = opts.Time.FormatTimes(events)
+	}
+	if opts.GroupField != "" {
+		switch format {
+		case formatSimple, formatTemplate:
+			return writeGroups(w, splitGroups(events, opts.GroupField), format, opts)
+		case formatTable:
+			return writeTableGroups(w, splitGroups(events, opts.GroupField), opts.WriteHeader, opts.Width)
+		}
+	}
 
 	switch format {
 	case formatSimple:
 		return WriteLogEventsSimple(w, events)
 	case formatCSV:
-		return WriteLogEventsCSV(w, events, writeHeader)
+		return WriteLogEventsCSV(w, events, opts.WriteHeader)
 	case formatJSON:
 		return WriteLogEventsJSON(w, events)
+	case formatTemplate:
+		return WriteLogEventsTemplate(w, events, opts.Template, opts.Time)
+	case formatJSONL:
+		return WriteLogEventsJSONL(w, events)
+	case formatTable:
+		return WriteLogEventsTable(w, events, opts.WriteHeader, opts.Width)
 	default:
 		return fmt.Errorf("unsupported output format: %s", format)
 	}
 }
 
+// splitGroups splits events into runs of the same value of the group field, removing the field
+func splitGroups(events [][]cwTypes.ResultField, field string) [][][]cwTypes.ResultField {
+	var groups [][][]cwTypes.ResultField
+	var current string
+	for i, event := range events {
+		value, _ := FieldValue(event, field)
+		if i == 0 || value != current {
+			groups = append(groups, nil)
+			current = value
+		}
+		kept := make([]cwTypes.ResultField, 0, len(event))
+		for _, f := range event {
+			if *f.Field != field {
+				kept = append(kept, f)
+			}
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], kept)
+	}
+	return groups
+}
+
+// writeGroups writes groups of events in the simple or template format with a separator line between them
+func writeGroups(w io.Writer, groups [][][]cwTypes.ResultField, format OutputFormat, opts WriteOptions) error {
+	for i, group := range groups {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, groupSeparator); err != nil {
+				return err
+			}
+		}
+		var err error
+		if format == formatTemplate {
+			err = WriteLogEventsTemplate(w, group, opts.Template, opts.Time)
+		} else {
+			err = WriteLogEventsSimple(w, group)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteLogEventsSimple writes CloudWatch log events in a simple format (one value per line)
 // This format can only be used when exactly one field is selected
 func WriteLogEventsSimple(w io.Writer, events [][]cwTypes.ResultField) error {
@@ -61,50 +147,56 @@ func WriteLogEventsSimple(w io.Writer, events [][]cwTypes.ResultField) error {
 	return nil
 }
 
-// WriteLogEventsCSV writes CloudWatch log events to a CSV file with optional headers
-func WriteLogEventsCSV(w io.Writer, events [][]cwTypes.ResultField, writeHeader bool) error {
+// tabulateEvents returns the fields of all events in the order they are first seen as column headers,
+// skipping the @ptr field, and the values of each event in the order of the headers
+func tabulateEvents(events [][]cwTypes.ResultField) ([]string, [][]string) {
 	if len(events) == 0 {
-		return nil
+		return nil, nil
 	}
-	csvWriter := csv.NewWriter(w)
-	defer csvWriter.Flush()
-	columnCount := len(events[0])
 
 	var headers []string
-	// convert event field index to csv column index
-	// we need this map since we skip @ptr field
-	indexMap := make([]int, columnCount)
-	// Write header if there are events
-	if len(events) > 0 && len(events[0]) > 0 {
-		headerIdx := 0
-		for i, field := range events[0] {
+	// column index of each field name
+	columns := make(map[string]int)
+	for _, event := range events {
+		for _, field := range event {
 			// Skip @ptr field
-			if *field.Field != "@ptr" {
-				indexMap[i] = headerIdx
-				headerIdx++
-				headers = append(headers, *field.Field)
-			}
-		}
-		if writeHeader {
-			if err := csvWriter.Write(headers); err != nil {
-				return err
+			if _, ok := columns[*field.Field]; ok || *field.Field == "@ptr" {
+				continue
 			}
+			columns[*field.Field] = len(headers)
+			headers = append(headers, *field.Field)
 		}
 	}
 
-	// Write data rows
-	for _, event := range events {
+	rows := make([][]string, len(events))
+	for i, event := range events {
 		row := make([]string, len(headers))
-		for i, field := range event {
-			// Skip @ptr field
-			if *field.Field != "@ptr" {
-				if field.Value != nil {
-					row[indexMap[i]] = *field.Value
-				} else {
-					row[indexMap[i]] = "" // Empty string for nil values
-				}
+		for _, field := range event {
+			if column, ok := columns[*field.Field]; ok && field.Value != nil {
+				row[column] = *field.Value
 			}
 		}
+		rows[i] = row
+	}
+	return headers, rows
+}
+
+// WriteLogEventsCSV writes CloudWatch log events to a CSV file with optional headers
+func WriteLogEventsCSV(w io.Writer, events [][]cwTypes.ResultField, writeHeader bool) error {
+	if len(events) == 0 {
+		return nil
+	}
+	csvWriter := csv.NewWriter(w)
+	defer csvWriter.Flush()
+
+	headers, rows := tabulateEvents(events)
+	if writeHeader {
+		if err := csvWriter.Write(headers); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
 		if err := csvWriter.Write(row); err != nil {
 			return err
 		}
@@ -121,20 +213,36 @@ func WriteLogEventsJSON(w io.Writer, events [][]cwTypes.ResultField) error {
 
 	var logs []map[string]string
 	for _, event := range events {
-		log := make(map[string]string)
-		for _, field := range event {
-			if *field.Field != "@ptr" {
-				if field.Value != nil {
-					log[*field.Field] = *field.Value
-				} else {
-					log[*field.Field] = ""
-				}
-			}
-		}
-		logs = append(logs, log)
+		logs = append(logs, eventMap(event))
 	}
 
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(logs)
 }
+
+// WriteLogEventsJSONL writes CloudWatch log events as JSON Lines, one compact object per event
+func WriteLogEventsJSONL(w io.Writer, events [][]cwTypes.ResultField) error {
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(eventMap(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventMap returns the fields of an event by name, skipping the @ptr field
+func eventMap(event []cwTypes.ResultField) map[string]string {
+	log := make(map[string]string)
+	for _, field := range event {
+		if *field.Field != "@ptr" {
+			if field.Value != nil {
+				log[*field.Field] = *field.Value
+			} else {
+				log[*field.Field] = ""
+			}
+		}
+	}
+	return log
+}
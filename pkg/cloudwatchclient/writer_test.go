@@ -69,6 +69,32 @@ func TestWriteLogEventsCSV_NoHeader(t *testing.T) {
 	}
 }
 
+// TestWriteLogEventsCSV_FieldsMissingFromFirstEvent tests that fields only present in later events get columns too.
+func TestWriteLogEventsCSV_FieldsMissingFromFirstEvent(t *testing.T) {
+	var buf bytes.Buffer
+	events := [][]cwTypes.ResultField{
+		{
+			{Field: ptr("time"), Value: ptr("2025-02-16T00:00:00Z")},
+			{Field: ptr("level"), Value: ptr("INFO")},
+		},
+		{
+			{Field: ptr("time"), Value: ptr("2025-02-16T00:00:01Z")},
+			{Field: ptr("error"), Value: ptr("timeout")},
+			{Field: ptr("level"), Value: ptr("ERROR")},
+		},
+	}
+
+	err := WriteLogEventsCSV(&buf, events, true)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expected := "time,level,error\n2025-02-16T00:00:00Z,INFO,\n2025-02-16T00:00:01Z,ERROR,timeout\n"
+	if buf.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, buf.String())
+	}
+}
+
 // TestWriteLogEventsCSV_JSONValue tests that a JSON string value is written correctly.
 func TestWriteLogEventsCSV_JSONValue(t *testing.T) {
 	var buf bytes.Buffer
@@ -158,3 +184,26 @@ func TestWriteLogEventsJSON_ComplexValue(t *testing.T) {
 		t.Errorf("Expected output %q, got %q", expected, buf.String())
 	}
 }
+
+// TestWriteLogEventsJSONL tests that each event is written as one compact JSON object per line.
+func TestWriteLogEventsJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	events := [][]cwTypes.ResultField{
+		{
+			{Field: ptr("@message"), Value: ptr("first")},
+			{Field: ptr("@ptr"), Value: ptr("ptr")},
+		},
+		{
+			{Field: ptr("@message"), Value: ptr("second")},
+			{Field: ptr("@ptr"), Value: ptr("ptr")},
+		},
+	}
+	if err := WriteLogEventsJSONL(&buf, events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "{\"@message\":\"first\"}\n{\"@message\":\"second\"}\n"
+	if buf.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,104 @@
+// Package completion provides the shell completion scripts and a short-lived cache
+// for the suggestions looked up from AWS.
+package completion
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is how long cached suggestions are used
+const DefaultTTL = 5 * time.Minute
+
+// DefaultCacheDir returns the directory used to cache suggestions
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ecs-log-viewer", "completion"), nil
+}
+
+// Cache stores suggestions in files, so that repeated completions do not call AWS every time
+type Cache struct {
+	Dir string
+	TTL time.Duration
+}
+
+type cacheEntry struct {
+	Values  []string  `json:"values"`
+	Expires time.Time `json:"expires"`
+}
+
+// Get returns the values cached for the key parts if they have not expired
+func (c Cache) Get(key ...string) ([]string, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Now().After(entry.Expires) {
+		return nil, false
+	}
+	return entry.Values, true
+}
+
+// Set caches the values for the key parts
+func (c Cache) Set(values []string, key ...string) error {
+	data, err := json.Marshal(cacheEntry{Values: values, Expires: time.Now().Add(c.TTL)})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(key), data, 0o600)
+}
+
+func (c Cache) path(key []string) string {
+	sum := sha1.Sum([]byte(strings.Join(key, "\x00")))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Filter returns the values starting with prefix
+func Filter(values []string, prefix string) []string {
+	var matched []string
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			matched = append(matched, value)
+		}
+	}
+	return matched
+}
+
+// FilterList completes the last item of a comma-separated list such as "@timestamp,@me".
+// The returned suggestions contain the preceding items, and items already in the list are skipped.
+func FilterList(values []string, list string) []string {
+	head, last := "", list
+	if i := strings.LastIndex(list, ","); i >= 0 {
+		head, last = list[:i+1], list[i+1:]
+	}
+	used := strings.Split(head, ",")
+
+	var matched []string
+	for _, value := range Filter(values, last) {
+		if !contains(used, value) {
+			matched = append(matched, head+value)
+		}
+	}
+	return matched
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
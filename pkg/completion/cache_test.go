@@ -0,0 +1,61 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	cache := Cache{Dir: t.TempDir(), TTL: time.Minute}
+
+	if _, ok := cache.Get("taskdef", "prod"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := cache.Set([]string{"api", "worker"}, "taskdef", "prod"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	values, ok := cache.Get("taskdef", "prod")
+	if !ok || !reflect.DeepEqual(values, []string{"api", "worker"}) {
+		t.Errorf("Get() = %v, %v", values, ok)
+	}
+	if _, ok := cache.Get("taskdef", "staging"); ok {
+		t.Error("expected a miss for another key")
+	}
+}
+
+func TestCache_Expired(t *testing.T) {
+	cache := Cache{Dir: t.TempDir(), TTL: -time.Second}
+	if err := cache.Set([]string{"api"}, "taskdef"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if _, ok := cache.Get("taskdef"); ok {
+		t.Error("expected an expired entry to miss")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	got := Filter([]string{"api", "api-worker", "batch"}, "api")
+	if want := []string{"api", "api-worker"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterList(t *testing.T) {
+	fields := []string{"@timestamp", "@message", "@logStream", "level"}
+
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{"@m", []string{"@message"}},
+		{"@timestamp,@", []string{"@timestamp,@message", "@timestamp,@logStream"}},
+		{"@message,level,", []string{"@message,level,@timestamp", "@message,level,@logStream"}},
+	}
+	for _, tt := range tests {
+		if got := FilterList(fields, tt.list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterList(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package completion
+
+import (
+	"fmt"
+	"strings"
+)
+
+// valueFlags are the flags completed with values looked up from AWS, by the kind passed to __complete
+var valueFlags = map[string][]string{
+	"taskdef":   {"--taskdef", "-t"},
+	"container": {"--container", "-c"},
+	"fields":    {"--fields"},
+}
+
+// contextFlags are passed on to __complete so that the suggestions come from the same account,
+// region, task definition and container as the command being typed
+var contextFlags = []string{
+	"--profile", "-p", "--region", "-r", "--role-arn", "--external-id",
+	"--taskdef", "-t", "--container", "-c", "--config",
+}
+
+// Script returns the completion script of the shell (bash, zsh or fish) for the program.
+// Command and flag names are completed by the program's --generate-bash-completion;
+// the values of --taskdef, --container and --fields by its hidden __complete command.
+func Script(shell, program string) (string, error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashScript
+	case "zsh":
+		script = zshScript
+	case "fish":
+		script = fishScript
+	default:
+		return "", fmt.Errorf("unsupported shell: %s (supported: bash, zsh, fish)", shell)
+	}
+
+	function := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(program)
+	return strings.NewReplacer(
+		"{{program}}", program,
+		"{{function}}", function,
+		"{{taskdefFlags}}", caseList(shell, valueFlags["taskdef"]),
+		"{{containerFlags}}", caseList(shell, valueFlags["container"]),
+		"{{fieldsFlags}}", caseList(shell, valueFlags["fields"]),
+		"{{contextFlags}}", caseList(shell, contextFlags),
+	).Replace(script), nil
+}
+
+// caseList joins flags as alternatives of a case pattern
+func caseList(shell string, flags []string) string {
+	if shell == "fish" {
+		return strings.Join(flags, " ")
+	}
+	return strings.Join(flags, "|")
+}
+
+const bashScript = `# bash completion for {{program}}
+# Load it with: source <({{program}} completion bash)
+{{function}}() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" kind="" i
+    case "$prev" in
+        {{taskdefFlags}}) kind=taskdef ;;
+        {{containerFlags}}) kind=container ;;
+        {{fieldsFlags}}) kind=fields ;;
+    esac
+
+    local IFS=$'\n'
+    if [[ -n "$kind" ]]; then
+        local args=()
+        for ((i = 1; i < COMP_CWORD - 1; i++)); do
+            case "${COMP_WORDS[i]}" in
+                {{contextFlags}}) args+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}") ;;
+            esac
+        done
+        COMPREPLY=($({{program}} "${args[@]}" __complete "$kind" "$cur" 2>/dev/null))
+        [[ "$kind" == fields ]] && compopt -o nospace 2>/dev/null
+        return
+    fi
+
+    COMPREPLY=($(compgen -W "$({{program}} "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" --generate-bash-completion 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F {{function}} {{program}}
+`
+
+const zshScript = `#compdef {{program}}
+# zsh completion for {{program}}
+# Load it with: source <({{program}} completion zsh)
+{{function}}() {
+    local -a suggestions args
+    local kind i
+    case "${words[CURRENT-1]}" in
+        {{taskdefFlags}}) kind=taskdef ;;
+        {{containerFlags}}) kind=container ;;
+        {{fieldsFlags}}) kind=fields ;;
+    esac
+
+    if [[ -n "$kind" ]]; then
+        for ((i = 2; i < CURRENT - 1; i++)); do
+            case "${words[i]}" in
+                {{contextFlags}}) args+=("${words[i]}" "${words[i+1]}") ;;
+            esac
+        done
+        suggestions=("${(@f)$({{program}} "${args[@]}" __complete "$kind" "${words[CURRENT]}" 2>/dev/null)}")
+        if [[ "$kind" == fields ]]; then
+            compadd -Q -S '' -a suggestions
+        else
+            compadd -a suggestions
+        fi
+        return
+    fi
+
+    suggestions=("${(@f)$({{program}} "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" --generate-bash-completion 2>/dev/null)}")
+    compadd -a suggestions || _files
+}
+compdef {{function}} {{program}}
+`
+
+const fishScript = `# fish completion for {{program}}
+# Load it with: {{program}} completion fish | source
+function {{function}}_kind
+    switch (commandline -opc)[-1]
+        case {{taskdefFlags}}
+            echo taskdef
+        case {{containerFlags}}
+            echo container
+        case {{fieldsFlags}}
+            echo fields
+        case '*'
+            return 1
+    end
+end
+
+function {{function}}_values
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    set -l args
+    for i in (seq (math (count $tokens) - 1))
+        if contains -- $tokens[$i] {{contextFlags}}
+            set -a args $tokens[$i] $tokens[(math $i + 1)]
+        end
+    end
+    {{program}} $args __complete ({{function}}_kind) (commandline -ct) 2>/dev/null
+end
+
+function {{function}}_words
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    {{program}} $tokens (commandline -ct) --generate-bash-completion 2>/dev/null
+end
+
+complete -c {{program}} -n '{{function}}_kind >/dev/null' -f -a '({{function}}_values)'
+complete -c {{program}} -n 'not {{function}}_kind >/dev/null' -a '({{function}}_words)'
+`
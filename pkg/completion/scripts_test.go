@@ -0,0 +1,48 @@
+package completion
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script, err := Script(shell, "ecs-log-viewer")
+		if err != nil {
+			t.Fatalf("Script(%q) error: %v", shell, err)
+		}
+		if strings.Contains(script, "{{") {
+			t.Errorf("Script(%q) has unreplaced placeholders", shell)
+		}
+		for _, want := range []string{"_ecs_log_viewer", "__complete", "--generate-bash-completion"} {
+			if !strings.Contains(script, want) {
+				t.Errorf("Script(%q) does not contain %q", shell, want)
+			}
+		}
+	}
+
+	if _, err := Script("powershell", "ecs-log-viewer"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+// TestScript_Syntax checks the scripts with the shells installed on the machine.
+func TestScript_Syntax(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		script, _ := Script(shell, "ecs-log-viewer")
+		file := filepath.Join(t.TempDir(), "completion."+shell)
+		if err := os.WriteFile(file, []byte(script), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(path, "-n", file).CombinedOutput(); err != nil {
+			t.Errorf("%s -n failed: %v\n%s", shell, err, out)
+		}
+	}
+}
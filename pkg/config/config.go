@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config is the content of the configuration file
+type Config struct {
+	Redact  RedactConfig   `json:"redact"`
+	Watch   WatchConfig    `json:"watch"`
+	Metrics []MetricConfig `json:"metrics"`
+}
+
+// RedactConfig configures the redaction of secrets and personal information in the output
+type RedactConfig struct {
+	// Enabled turns redaction on without the --redact flag
+	Enabled bool `json:"enabled"`
+	// Rules are applied in addition to the built-in detectors
+	Rules []RedactRule `json:"rules"`
+}
+
+// RedactRule is a user-defined redaction rule
+type RedactRule struct {
+	// Name is used in the placeholder, e.g. "customer_id" produces <CUSTOMER_ID_1>
+	Name string `json:"name"`
+	// Pattern is a regular expression. If it has a capture group, only the first group is replaced.
+	Pattern string `json:"pattern"`
+}
+
+// WatchConfig configures the rules and notifications of the watch command.
+// They are used in addition to the ones given with flags.
+type WatchConfig struct {
+	// Rules are alert rules such as "/ERROR/ > 20 in 5m" or "/OutOfMemory/"
+	Rules []string `json:"rules"`
+	// Notify shows desktop notifications
+	Notify bool `json:"notify"`
+	// Exec is a shell command run for each alert
+	Exec string `json:"exec"`
+	// Webhook is a URL each alert is posted to
+	Webhook string `json:"webhook"`
+}
+
+// MetricConfig is a Logs Insights query run periodically by the metrics-exporter command
+type MetricConfig struct {
+	// Name prefixes the metrics, one per value column of the query (e.g. api_log_lines_count)
+	Name string `json:"name"`
+	Help string `json:"help"`
+	// TaskDef and Container select the logs to query
+	TaskDef   string `json:"taskdef"`
+	Container string `json:"container"`
+	// Filter limits the query to messages containing the text
+	Filter string `json:"filter"`
+	// Query are the Insights commands run on the container's logs, e.g. "stats count(*) as count by level"
+	Query string `json:"query"`
+	// Labels are the columns of the results exposed as labels, typically the "by" fields of stats
+	Labels []string `json:"labels"`
+	// Window is the time range queried on every run (e.g. "5m")
+	Window string `json:"window"`
+}
+
+// DefaultPath returns the path of the configuration file used when none is specified
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ecs-log-viewer", "config.json"), nil
+}
+
+// Load reads the configuration file at path. If path is empty, the file at DefaultPath is
+// read if it exists, and an empty configuration is returned otherwise.
+func Load(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return &Config{}, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return &cfg, nil
+}
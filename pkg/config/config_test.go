@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"redact": {"enabled": true, "rules": [{"name": "customer_id", "pattern": "cus_[a-z0-9]+"}]}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.Redact.Enabled || len(cfg.Redact.Rules) != 1 || cfg.Redact.Rules[0].Name != "customer_id" {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
+
+func TestLoad_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"watch": {"rules": ["/ERROR/ > 20 in 5m", "/OutOfMemory/"], "webhook": "http://localhost:9000/alerts"}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Watch.Rules) != 2 || cfg.Watch.Webhook != "http://localhost:9000/alerts" {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
+
+// TestLoad_MissingExplicitFile tests that a file given explicitly must exist.
+func TestLoad_MissingExplicitFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
@@ -3,6 +3,9 @@ package ecsclient
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
@@ -129,3 +132,296 @@ type TaskDefFamily struct {
 func (t TaskDefFamily) Label() string {
 	return t.Name
 }
+
+// ListClusters retrieves all ECS clusters
+func (e *EcsClient) ListClusters() ([]Cluster, error) {
+	var clusters []Cluster
+	input := &ecs.ListClustersInput{}
+	for {
+		resp, err := e.client.ListClusters(e.ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, arn := range resp.ClusterArns {
+			clusters = append(clusters, Cluster{Arn: arn})
+		}
+
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	return clusters, nil
+}
+
+// ListServices retrieves the ARNs of all services in a cluster
+func (e *EcsClient) ListServices(cluster string) ([]string, error) {
+	var serviceArns []string
+	input := &ecs.ListServicesInput{Cluster: aws.String(cluster)}
+	for {
+		resp, err := e.client.ListServices(e.ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceArns = append(serviceArns, resp.ServiceArns...)
+
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	return serviceArns, nil
+}
+
+// describeServicesBatchSize is the maximum number of services accepted by DescribeServices
+const describeServicesBatchSize = 10
+
+// DescribeServices retrieves details, including recent events and deployments, for services in a cluster
+func (e *EcsClient) DescribeServices(cluster string, services []string) ([]Service, error) {
+	var described []Service
+	for start := 0; start < len(services); start += describeServicesBatchSize {
+		end := start + describeServicesBatchSize
+		if end > len(services) {
+			end = len(services)
+		}
+
+		resp, err := e.client.DescribeServices(e.ctx, &ecs.DescribeServicesInput{
+			Cluster:  aws.String(cluster),
+			Services: services[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Failures) > 0 {
+			failure := resp.Failures[0]
+			return nil, fmt.Errorf("failed to describe service %s: %s", aws.ToString(failure.Arn), aws.ToString(failure.Reason))
+		}
+
+		for _, service := range resp.Services {
+			described = append(described, Service{Service: service})
+		}
+	}
+	return described, nil
+}
+
+// describeServiceRevisionsBatchSize is the maximum number of service revisions accepted by DescribeServiceRevisions
+const describeServiceRevisionsBatchSize = 20
+
+// DeploymentTimes returns when each task definition (family:revision) was first deployed to the service,
+// from the current deployments of the service and the service deployments ECS keeps (about 90 days)
+func (e *EcsClient) DeploymentTimes(cluster string, service Service) (map[string]time.Time, error) {
+	input := &ecs.ListServiceDeploymentsInput{
+		Cluster: aws.String(cluster),
+		Service: service.ServiceArn,
+	}
+	var briefs []ecsTypes.ServiceDeploymentBrief
+	for {
+		resp, err := e.client.ListServiceDeployments(e.ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		briefs = append(briefs, resp.ServiceDeployments...)
+
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+
+	var revisionArns []string
+	for _, brief := range briefs {
+		if arn := aws.ToString(brief.TargetServiceRevisionArn); arn != "" {
+			revisionArns = append(revisionArns, arn)
+		}
+	}
+	taskDefs := make(map[string]string)
+	for start := 0; start < len(revisionArns); start += describeServiceRevisionsBatchSize {
+		end := min(start+describeServiceRevisionsBatchSize, len(revisionArns))
+		resp, err := e.client.DescribeServiceRevisions(e.ctx, &ecs.DescribeServiceRevisionsInput{
+			ServiceRevisionArns: revisionArns[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, revision := range resp.ServiceRevisions {
+			taskDefs[aws.ToString(revision.ServiceRevisionArn)] = aws.ToString(revision.TaskDefinition)
+		}
+	}
+
+	return deploymentTimes(service.Deployments, briefs, taskDefs), nil
+}
+
+// deploymentTimes returns the earliest creation time of the deployments of each task definition.
+// taskDefs maps the service revisions the deployment briefs target to their task definitions.
+func deploymentTimes(current []ecsTypes.Deployment, briefs []ecsTypes.ServiceDeploymentBrief, taskDefs map[string]string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	record := func(taskDef string, at *time.Time) {
+		if taskDef == "" || at == nil {
+			return
+		}
+		name := ResourceName(taskDef)
+		if earliest, ok := times[name]; !ok || at.Before(earliest) {
+			times[name] = *at
+		}
+	}
+
+	for _, deployment := range current {
+		record(aws.ToString(deployment.TaskDefinition), deployment.CreatedAt)
+	}
+	for _, brief := range briefs {
+		record(taskDefs[aws.ToString(brief.TargetServiceRevisionArn)], brief.CreatedAt)
+	}
+	return times
+}
+
+// Cluster represents an ECS cluster
+type Cluster struct {
+	Arn string
+}
+
+// Label returns the display label for the cluster
+func (c Cluster) Label() string {
+	return ResourceName(c.Arn)
+}
+
+// Service represents an ECS service
+type Service struct {
+	ecsTypes.Service
+}
+
+// Label returns the display label for the service
+func (s Service) Label() string {
+	return aws.ToString(s.ServiceName)
+}
+
+// ResourceName returns the resource name of an ARN such as arn:aws:ecs:region:account:cluster/name
+func ResourceName(arn string) string {
+	if i := strings.LastIndex(arn, "/"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
+
+// TaskDefinitionFamily returns the family of a task definition ARN or family:revision string
+func TaskDefinitionFamily(taskDef string) string {
+	name := ResourceName(taskDef)
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// describeTasksBatchSize is the maximum number of tasks accepted by DescribeTasks
+const describeTasksBatchSize = 100
+
+// ListStoppedTasks retrieves the stopped tasks of a service, or of a task definition family when
+// serviceName is empty, sorted by stop time with the most recent first.
+// ECS only keeps stopped tasks for a short time (typically about an hour).
+func (e *EcsClient) ListStoppedTasks(cluster, serviceName, family string) ([]Task, error) {
+	input := &ecs.ListTasksInput{
+		Cluster:       aws.String(cluster),
+		DesiredStatus: ecsTypes.DesiredStatusStopped,
+	}
+	if serviceName != "" {
+		input.ServiceName = aws.String(serviceName)
+	} else if family != "" {
+		input.Family = aws.String(family)
+	}
+
+	var taskArns []string
+	for {
+		resp, err := e.client.ListTasks(e.ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		taskArns = append(taskArns, resp.TaskArns...)
+
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+
+	var tasks []Task
+	for start := 0; start < len(taskArns); start += describeTasksBatchSize {
+		end := start + describeTasksBatchSize
+		if end > len(taskArns) {
+			end = len(taskArns)
+		}
+
+		resp, err := e.client.DescribeTasks(e.ctx, &ecs.DescribeTasksInput{
+			Cluster: aws.String(cluster),
+			Tasks:   taskArns[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range resp.Tasks {
+			tasks = append(tasks, Task{Task: task})
+		}
+	}
+
+	sortByStopTime(tasks)
+	return tasks, nil
+}
+
+// sortByStopTime sorts tasks by stop time with the most recent first.
+// Tasks that are still stopping have no stop time yet and come before all others.
+func sortByStopTime(tasks []Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].StoppedAt == nil || tasks[j].StoppedAt == nil {
+			return tasks[i].StoppedAt == nil && tasks[j].StoppedAt != nil
+		}
+		return tasks[i].StoppedAt.After(*tasks[j].StoppedAt)
+	})
+}
+
+// Task represents an ECS task
+type Task struct {
+	ecsTypes.Task
+}
+
+// ID returns the task ID, which is also the last part of the task's log stream names
+func (t Task) ID() string {
+	return ResourceName(aws.ToString(t.TaskArn))
+}
+
+// StoppedTime returns the local stop time of the task, or "stopping" if it has not stopped yet
+func (t Task) StoppedTime() string {
+	if t.StoppedAt == nil {
+		return "stopping"
+	}
+	return t.StoppedAt.Local().Format(time.RFC3339)
+}
+
+// Label returns the display label for the task including why it stopped
+func (t Task) Label() string {
+	label := fmt.Sprintf("%s  %s  %s", t.ID(), t.StoppedTime(), aws.ToString(t.StoppedReason))
+	if exits := t.ContainerExits(); exits != "" {
+		label += "  (" + exits + ")"
+	}
+	return label
+}
+
+// ContainerExits summarizes the exit code and reason of each container that has exited
+func (t Task) ContainerExits() string {
+	var exits []string
+	for _, container := range t.Containers {
+		if container.ExitCode == nil && container.Reason == nil {
+			continue
+		}
+		exit := aws.ToString(container.Name) + ":"
+		if container.ExitCode != nil {
+			exit += fmt.Sprintf(" exit %d", *container.ExitCode)
+		}
+		if reason := aws.ToString(container.Reason); reason != "" {
+			exit += " " + reason
+		}
+		exits = append(exits, exit)
+	}
+	return strings.Join(exits, ", ")
+}
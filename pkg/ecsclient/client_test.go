@@ -0,0 +1,88 @@
+package ecsclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+func TestTaskDefinitionFamily(t *testing.T) {
+	tests := []struct {
+		name    string
+		taskDef string
+		want    string
+	}{
+		{name: "arn", taskDef: "arn:aws:ecs:us-east-1:123456789012:task-definition/web-app:42", want: "web-app"},
+		{name: "family and revision", taskDef: "web-app:42", want: "web-app"},
+		{name: "family only", taskDef: "web-app", want: "web-app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TaskDefinitionFamily(tt.taskDef); got != tt.want {
+				t.Errorf("TaskDefinitionFamily() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeploymentTimes(t *testing.T) {
+	at := func(hour int) *time.Time {
+		t := time.Date(2026, 10, 16, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	current := []ecsTypes.Deployment{
+		{TaskDefinition: aws.String("arn:aws:ecs:us-east-1:123456789012:task-definition/web:42"), CreatedAt: at(12)},
+	}
+	briefs := []ecsTypes.ServiceDeploymentBrief{
+		{TargetServiceRevisionArn: aws.String("rev-41"), CreatedAt: at(9)},
+		{TargetServiceRevisionArn: aws.String("rev-42-first"), CreatedAt: at(10)},
+		{TargetServiceRevisionArn: aws.String("rev-42"), CreatedAt: at(12)},
+		{TargetServiceRevisionArn: aws.String("rev-unknown"), CreatedAt: at(11)},
+	}
+	taskDefs := map[string]string{
+		"rev-41":       "arn:aws:ecs:us-east-1:123456789012:task-definition/web:41",
+		"rev-42-first": "arn:aws:ecs:us-east-1:123456789012:task-definition/web:42",
+		"rev-42":       "arn:aws:ecs:us-east-1:123456789012:task-definition/web:42",
+	}
+
+	times := deploymentTimes(current, briefs, taskDefs)
+
+	if len(times) != 2 {
+		t.Fatalf("Expected the times of 2 task definitions, got %v", times)
+	}
+	if got := times["web:41"]; !got.Equal(*at(9)) {
+		t.Errorf("web:41 deployed at %v, want %v", got, *at(9))
+	}
+	// a revision deployed again, e.g. after a rollback, keeps the time it was first deployed
+	if got := times["web:42"]; !got.Equal(*at(10)) {
+		t.Errorf("web:42 deployed at %v, want %v", got, *at(10))
+	}
+}
+
+func TestSortByStopTime(t *testing.T) {
+	at := func(hour int) *time.Time {
+		t := time.Date(2026, 10, 16, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	task := func(id string, stoppedAt *time.Time) Task {
+		return Task{Task: ecsTypes.Task{TaskArn: aws.String("arn:aws:ecs:us-east-1:123456789012:task/cluster/" + id), StoppedAt: stoppedAt}}
+	}
+	tasks := []Task{task("old", at(9)), task("stopping", nil), task("new", at(11))}
+
+	sortByStopTime(tasks)
+
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.ID())
+	}
+	if want := []string{"stopping", "new", "old"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected tasks in order %v, got %v", want, ids)
+	}
+	if got := tasks[0].StoppedTime(); got != "stopping" {
+		t.Errorf("Expected the stop time of a stopping task to be %q, got %q", "stopping", got)
+	}
+}
@@ -0,0 +1,170 @@
+package histogram
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/loglevel"
+)
+
+// Bucket holds the number of events within a time interval
+type Bucket struct {
+	Start   time.Time
+	Count   int
+	ByLevel map[loglevel.Level]int
+}
+
+// Histogram is the distribution of events over a time range
+type Histogram struct {
+	Buckets  []Bucket
+	Interval time.Duration
+}
+
+// New creates a histogram with the given number of equal buckets between start and end
+func New(start, end time.Time, buckets int) *Histogram {
+	if buckets < 1 {
+		buckets = 1
+	}
+	interval := end.Sub(start) / time.Duration(buckets)
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	h := &Histogram{Interval: interval}
+	for i := 0; i < buckets; i++ {
+		h.Buckets = append(h.Buckets, Bucket{
+			Start:   start.Add(time.Duration(i) * interval),
+			ByLevel: make(map[loglevel.Level]int),
+		})
+	}
+	return h
+}
+
+// Add counts an event in the bucket containing t. Events outside the range are ignored.
+func (h *Histogram) Add(t time.Time, level loglevel.Level) {
+	if len(h.Buckets) == 0 || t.Before(h.Buckets[0].Start) {
+		return
+	}
+	i := int(t.Sub(h.Buckets[0].Start) / h.Interval)
+	if i == len(h.Buckets) && t.Equal(h.Buckets[0].Start.Add(time.Duration(i)*h.Interval)) {
+		// the end of the range belongs to the last bucket
+		i--
+	}
+	if i >= len(h.Buckets) {
+		return
+	}
+	h.Buckets[i].Count++
+	h.Buckets[i].ByLevel[level]++
+}
+
+// max returns the largest bucket count
+func (h *Histogram) max() int {
+	largest := 0
+	for _, b := range h.Buckets {
+		if b.Count > largest {
+			largest = b.Count
+		}
+	}
+	return largest
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline returns a single line representation of the histogram
+func (h *Histogram) Sparkline() string {
+	largest := h.max()
+	var sb strings.Builder
+	for _, b := range h.Buckets {
+		if b.Count == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		i := (b.Count*len(sparkBlocks) - 1) / largest
+		sb.WriteRune(sparkBlocks[i])
+	}
+	return sb.String()
+}
+
+// levelBlocks are the characters used for each level in stacked bars
+var levelBlocks = map[loglevel.Level]rune{
+	loglevel.Error:   '█',
+	loglevel.Warn:    '▓',
+	loglevel.Info:    '▒',
+	loglevel.Debug:   '░',
+	loglevel.Unknown: '·',
+}
+
+// Render draws the histogram as a horizontal bar chart with bars up to width characters.
+// When byLevel is true, each bar is split into segments per log level.
+func (h *Histogram) Render(w io.Writer, width int, byLevel bool) error {
+	if width < 1 {
+		width = 1
+	}
+	largest := h.max()
+	timeLayout := "01-02 15:04"
+	if h.Interval < time.Minute {
+		timeLayout = "01-02 15:04:05"
+	}
+
+	if _, err := fmt.Fprintf(w, "%s │%s│ max %d per %s\n", strings.Repeat(" ", len(timeLayout)), h.Sparkline(), largest, h.Interval); err != nil {
+		return err
+	}
+
+	for _, b := range h.Buckets {
+		var bar string
+		if byLevel {
+			bar = stackedBar(b, largest, width)
+		} else {
+			bar = strings.Repeat("█", scale(b.Count, largest, width))
+		}
+
+		line := fmt.Sprintf("%s │%-*s %d", b.Start.Local().Format(timeLayout), width, bar, b.Count)
+		if byLevel && b.Count > 0 {
+			line += fmt.Sprintf(" (error %d, warn %d)", b.ByLevel[loglevel.Error], b.ByLevel[loglevel.Warn])
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	if byLevel {
+		var legend []string
+		for _, level := range loglevel.Levels {
+			legend = append(legend, fmt.Sprintf("%c %s", levelBlocks[level], level))
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s\n", strings.Repeat(" ", len(timeLayout)), strings.Join(legend, "  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackedBar returns a bar whose segments are proportional to the count of each level
+func stackedBar(b Bucket, largest, width int) string {
+	var sb strings.Builder
+	total := scale(b.Count, largest, width)
+	drawn := 0
+	cumulative := 0
+	for _, level := range loglevel.Levels {
+		cumulative += b.ByLevel[level]
+		// scale the cumulative count so that rounding never exceeds the total bar length
+		end := scale(cumulative, b.Count, total)
+		sb.WriteString(strings.Repeat(string(levelBlocks[level]), end-drawn))
+		drawn = end
+	}
+	return sb.String()
+}
+
+// scale maps count in [0, largest] to [0, width], drawing at least one character for non-zero counts
+func scale(count, largest, width int) int {
+	if count == 0 || largest == 0 {
+		return 0
+	}
+	n := count * width / largest
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
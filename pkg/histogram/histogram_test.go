@@ -0,0 +1,57 @@
+package histogram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/loglevel"
+)
+
+func TestHistogram_Add(t *testing.T) {
+	start := time.Date(2025, 2, 16, 10, 0, 0, 0, time.UTC)
+	h := New(start, start.Add(time.Hour), 4)
+
+	h.Add(start, loglevel.Info)
+	h.Add(start.Add(10*time.Minute), loglevel.Error)
+	h.Add(start.Add(50*time.Minute), loglevel.Error)
+	h.Add(start.Add(time.Hour), loglevel.Warn)
+	h.Add(start.Add(-time.Minute), loglevel.Info)
+	h.Add(start.Add(2*time.Hour), loglevel.Info)
+
+	want := []int{2, 0, 0, 2}
+	for i, b := range h.Buckets {
+		if b.Count != want[i] {
+			t.Errorf("bucket %d: expected count %d, got %d", i, want[i], b.Count)
+		}
+	}
+	if h.Buckets[0].ByLevel[loglevel.Error] != 1 {
+		t.Errorf("Expected 1 error in first bucket, got %d", h.Buckets[0].ByLevel[loglevel.Error])
+	}
+}
+
+func TestHistogram_Render(t *testing.T) {
+	start := time.Date(2025, 2, 16, 10, 0, 0, 0, time.UTC)
+	h := New(start, start.Add(2*time.Minute), 2)
+	for i := 0; i < 4; i++ {
+		h.Add(start, loglevel.Error)
+	}
+	h.Add(start.Add(90*time.Second), loglevel.Info)
+
+	var buf bytes.Buffer
+	if err := h.Render(&buf, 8, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected sparkline, 2 bars and legend, got %q", buf.String())
+	}
+	if !strings.Contains(lines[1], "████████ 4 (error 4, warn 0)") {
+		t.Errorf("Unexpected first bar %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "▒▒       1") {
+		t.Errorf("Unexpected second bar %q", lines[2])
+	}
+}
@@ -0,0 +1,112 @@
+// Package logfields discovers the fields available in Logs Insights queries of a log group,
+// from the fields Insights reports and the keys of sampled JSON messages.
+package logfields
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// Source tells where a field was discovered
+type Source string
+
+const (
+	// SourceLogGroup fields are reported by GetLogGroupFields for the whole log group
+	SourceLogGroup Source = "log group"
+	// SourceJSON fields are keys of the sampled JSON messages
+	SourceJSON Source = "json"
+)
+
+// Field is a queryable field with the percentage of events containing it
+type Field struct {
+	Name    string
+	Percent int
+	Source  Source
+}
+
+// Label returns the display label for the field selector
+func (f Field) Label() string {
+	return fmt.Sprintf("%s (%d%%)", f.Name, f.Percent)
+}
+
+// DiscoverJSONKeys returns the keys of the JSON object messages, with nested keys joined by dots
+// as Insights names them (e.g. req.id). The percentage is relative to all messages.
+func DiscoverJSONKeys(messages []string) []Field {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, message := range messages {
+		var object map[string]any
+		if err := json.Unmarshal([]byte(message), &object); err != nil {
+			continue
+		}
+		keys := make(map[string]bool)
+		collectKeys(object, "", keys)
+		for key := range keys {
+			counts[key]++
+		}
+	}
+
+	fields := make([]Field, 0, len(counts))
+	for name, count := range counts {
+		fields = append(fields, Field{Name: name, Percent: count * 100 / len(messages), Source: SourceJSON})
+	}
+	sortFields(fields)
+	return fields
+}
+
+func collectKeys(object map[string]any, prefix string, keys map[string]bool) {
+	for key, value := range object {
+		name := prefix + key
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			collectKeys(nested, name+".", keys)
+			continue
+		}
+		keys[name] = true
+	}
+}
+
+// Merge combines the fields of the log group with the discovered JSON keys. A field found
+// in both is listed once, with the log group's percentage.
+func Merge(logGroupFields []cloudwatchclient.LogGroupField, jsonFields []Field) []Field {
+	fields := make([]Field, 0, len(logGroupFields)+len(jsonFields))
+	seen := make(map[string]bool)
+	for _, field := range logGroupFields {
+		fields = append(fields, Field{Name: field.Name, Percent: field.Percent, Source: SourceLogGroup})
+		seen[field.Name] = true
+	}
+	for _, field := range jsonFields {
+		if !seen[field.Name] {
+			fields = append(fields, field)
+		}
+	}
+	sortFields(fields)
+	return fields
+}
+
+// sortFields sorts fields by frequency, most frequent first, then by name
+func sortFields(fields []Field) {
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].Percent != fields[j].Percent {
+			return fields[i].Percent > fields[j].Percent
+		}
+		return fields[i].Name < fields[j].Name
+	})
+}
+
+// WriteFields writes the fields as a table with their frequency and source
+func WriteFields(w io.Writer, fields []Field) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tFREQUENCY\tSOURCE")
+	for _, field := range fields {
+		fmt.Fprintf(tw, "%s\t%d%%\t%s\n", field.Name, field.Percent, field.Source)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,70 @@
+package logfields
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+func TestDiscoverJSONKeys(t *testing.T) {
+	messages := []string{
+		`{"level":"info","req":{"id":"r1","path":"/"}}`,
+		`{"level":"error","error":"timeout"}`,
+		`plain text`,
+		`{"level":"info","tags":[],"meta":{}}`,
+	}
+
+	got := DiscoverJSONKeys(messages)
+	want := []Field{
+		{Name: "level", Percent: 75, Source: SourceJSON},
+		{Name: "error", Percent: 25, Source: SourceJSON},
+		{Name: "meta", Percent: 25, Source: SourceJSON},
+		{Name: "req.id", Percent: 25, Source: SourceJSON},
+		{Name: "req.path", Percent: 25, Source: SourceJSON},
+		{Name: "tags", Percent: 25, Source: SourceJSON},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiscoverJSONKeys() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	logGroupFields := []cloudwatchclient.LogGroupField{
+		{Name: "@message", Percent: 100},
+		{Name: "level", Percent: 60},
+	}
+	jsonFields := []Field{
+		{Name: "level", Percent: 75, Source: SourceJSON},
+		{Name: "req.id", Percent: 80, Source: SourceJSON},
+	}
+
+	got := Merge(logGroupFields, jsonFields)
+	want := []Field{
+		{Name: "@message", Percent: 100, Source: SourceLogGroup},
+		{Name: "req.id", Percent: 80, Source: SourceJSON},
+		{Name: "level", Percent: 60, Source: SourceLogGroup},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteFields(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteFields(&buf, []Field{
+		{Name: "@message", Percent: 100, Source: SourceLogGroup},
+		{Name: "req.id", Percent: 8, Source: SourceJSON},
+	})
+	if err != nil {
+		t.Fatalf("WriteFields() error: %v", err)
+	}
+
+	want := "FIELD     FREQUENCY  SOURCE\n" +
+		"@message  100%       log group\n" +
+		"req.id    8%         json\n"
+	if buf.String() != want {
+		t.Errorf("WriteFields() =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
@@ -0,0 +1,70 @@
+package loglevel
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Level is the severity of a log message
+type Level string
+
+// Supported log levels, from most to least severe
+const (
+	Error   Level = "error"
+	Warn    Level = "warn"
+	Info    Level = "info"
+	Debug   Level = "debug"
+	Unknown Level = "unknown"
+)
+
+// Levels lists all levels from most to least severe
+var Levels = []Level{Error, Warn, Info, Debug, Unknown}
+
+// jsonLevelKeys are the keys commonly used by structured loggers for the level
+var jsonLevelKeys = []string{"level", "severity", "lvl", "log.level"}
+
+// levelPattern matches upper case level names and logfmt style level keys
+var levelPattern = regexp.MustCompile(`\b(FATAL|PANIC|CRITICAL|ERROR|ERR|WARNING|WARN|INFO|DEBUG|TRACE)\b|\blevel=(\w+)`)
+
+// Detect returns the level of a log message.
+// Structured (JSON) messages are detected by their level key, plain text messages
+// by the first level name they contain.
+func Detect(message string) Level {
+	trimmed := strings.TrimSpace(message)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			for _, key := range jsonLevelKeys {
+				if value, ok := fields[key].(string); ok {
+					return Parse(value)
+				}
+			}
+		}
+	}
+
+	match := levelPattern.FindStringSubmatch(message)
+	if match == nil {
+		return Unknown
+	}
+	if match[1] != "" {
+		return Parse(match[1])
+	}
+	return Parse(match[2])
+}
+
+// Parse converts a level name such as "WARNING" or "err" to a Level
+func Parse(name string) Level {
+	switch strings.ToLower(name) {
+	case "fatal", "panic", "critical", "crit", "error", "err", "alert", "emergency":
+		return Error
+	case "warning", "warn":
+		return Warn
+	case "info", "notice", "information":
+		return Info
+	case "debug", "trace":
+		return Debug
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,26 @@
+package loglevel
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    Level
+	}{
+		{name: "plain error", message: "2025-02-16 10:00:00 ERROR failed to connect", want: Error},
+		{name: "plain warning", message: "[WARNING] disk almost full", want: Warn},
+		{name: "logfmt", message: `time=2025-02-16T10:00:00Z level=debug msg="cache miss"`, want: Debug},
+		{name: "json level", message: `{"level":"info","msg":"started"}`, want: Info},
+		{name: "json severity", message: `{"severity":"CRITICAL","msg":"down"}`, want: Error},
+		{name: "lower case words are not levels", message: "no error occurred", want: Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.message); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
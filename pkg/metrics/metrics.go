@@ -0,0 +1,195 @@
+// Package metrics converts the results of Logs Insights stats queries into metrics and exposes
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Sample is a value of a metric with its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Metric is a gauge with its samples
+type Metric struct {
+	Name    string
+	Help    string
+	Samples []Sample
+}
+
+var (
+	invalidNameChars      = regexp.MustCompile(`[^a-zA-Z0-9_:]+`)
+	invalidLabelNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+)
+
+// SanitizeName turns s into a valid metric name
+func SanitizeName(s string) string {
+	return sanitize(s, invalidNameChars)
+}
+
+// SanitizeLabelName turns s into a valid label name, which unlike a metric name cannot contain colons
+func SanitizeLabelName(s string) string {
+	return sanitize(s, invalidLabelNameChars)
+}
+
+// sanitize replaces the runs of invalid characters of s with _ and makes sure it does not start with a digit
+func sanitize(s string, invalidChars *regexp.Regexp) string {
+	name := strings.Trim(invalidChars.ReplaceAllString(s, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// FromResults converts the rows of a stats query into metrics named prefix_<column>. Columns listed
+// in labelFields (typically the "by" fields) become labels together with constLabels; the other
+// columns are values. Values that are not numbers are skipped.
+func FromResults(prefix, help string, events [][]cwTypes.ResultField, labelFields []string, constLabels map[string]string) []Metric {
+	metrics := make(map[string]*Metric)
+	for _, event := range events {
+		labels := make(map[string]string, len(constLabels)+len(labelFields))
+		for k, v := range constLabels {
+			labels[k] = v
+		}
+		for _, field := range event {
+			if field.Field != nil && containsString(labelFields, *field.Field) {
+				labels[SanitizeLabelName(*field.Field)] = valueOf(field)
+			}
+		}
+
+		for _, field := range event {
+			if field.Field == nil || *field.Field == "@ptr" || containsString(labelFields, *field.Field) {
+				continue
+			}
+			value, err := strconv.ParseFloat(valueOf(field), 64)
+			if err != nil {
+				continue
+			}
+
+			name := SanitizeName(prefix + "_" + *field.Field)
+			metric, ok := metrics[name]
+			if !ok {
+				metric = &Metric{Name: name, Help: help}
+				metrics[name] = metric
+			}
+			metric.Samples = append(metric.Samples, Sample{Labels: labels, Value: value})
+		}
+	}
+
+	result := make([]Metric, 0, len(metrics))
+	for _, metric := range metrics {
+		result = append(result, *metric)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// WriteText writes the metrics as gauges in the Prometheus text exposition format.
+// Metrics with the same name are merged into one family.
+func WriteText(w io.Writer, metrics []Metric) error {
+	families := make(map[string]*Metric)
+	var names []string
+	for _, metric := range metrics {
+		family, ok := families[metric.Name]
+		if !ok {
+			family = &Metric{Name: metric.Name, Help: metric.Help}
+			families[metric.Name] = family
+			names = append(names, metric.Name)
+		}
+		family.Samples = append(family.Samples, metric.Samples...)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := families[name]
+		if family.Help != "" {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(family.Help)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s gauge\n", name); err != nil {
+			return err
+		}
+
+		lines := make([]string, len(family.Samples))
+		for i, sample := range family.Samples {
+			lines[i] = name + formatLabels(sample.Labels) + " " + formatValue(sample.Value)
+		}
+		sort.Strings(lines)
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func valueOf(field cwTypes.ResultField) string {
+	if field.Value == nil {
+		return ""
+	}
+	return *field.Value
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func row(fields ...string) []cwTypes.ResultField {
+	var event []cwTypes.ResultField
+	for i := 0; i < len(fields); i += 2 {
+		event = append(event, cwTypes.ResultField{Field: stringPtr(fields[i]), Value: stringPtr(fields[i+1])})
+	}
+	return event
+}
+
+func TestFromResults(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		row("level", "ERROR", "count", "3", "p99", "120.5"),
+		row("level", "INFO", "count", "42", "p99", "n/a"),
+	}
+	constLabels := map[string]string{"container": "app"}
+
+	metrics := FromResults("api_log", "Log lines", events, []string{"level"}, constLabels)
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2: %+v", len(metrics), metrics)
+	}
+	if metrics[0].Name != "api_log_count" || len(metrics[0].Samples) != 2 {
+		t.Errorf("unexpected count metric: %+v", metrics[0])
+	}
+	if metrics[1].Name != "api_log_p99" || len(metrics[1].Samples) != 1 || metrics[1].Samples[0].Value != 120.5 {
+		t.Errorf("unexpected p99 metric: %+v", metrics[1])
+	}
+	if labels := metrics[0].Samples[0].Labels; labels["level"] != "ERROR" || labels["container"] != "app" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	metrics := []Metric{
+		{Name: "b_total", Help: "B", Samples: []Sample{{Value: 1}}},
+		{Name: "a_lines", Help: "Lines\nper level", Samples: []Sample{
+			{Labels: map[string]string{"level": "INFO", "msg": `say "hi"`}, Value: 42},
+		}},
+		{Name: "a_lines", Samples: []Sample{
+			{Labels: map[string]string{"level": "ERROR"}, Value: 0.5},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, metrics); err != nil {
+		t.Fatalf("WriteText() error: %v", err)
+	}
+
+	want := `# HELP a_lines Lines\nper level
+# TYPE a_lines gauge
+a_lines{level="ERROR"} 0.5
+a_lines{level="INFO",msg="say \"hi\""} 42
+# HELP b_total B
+# TYPE b_total gauge
+b_total 1
+`
+	if buf.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"api_log_count(*)": "api_log_count",
+		"p99-latency":      "p99_latency",
+		"@message":         "message",
+		"99th":             "_99th",
+		"http:requests":    "http:requests",
+	}
+	for input, want := range tests {
+		if got := SanitizeName(input); got != want {
+			t.Errorf("SanitizeName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	tests := map[string]string{
+		"status_code":   "status_code",
+		"@logStream":    "logStream",
+		"http:method":   "http_method",
+		"bin(5m)":       "bin_5m",
+		"2xx":           "_2xx",
+		"::":            "_",
+		"kubernetes.io": "kubernetes_io",
+	}
+	for input, want := range tests {
+		if got := SanitizeLabelName(input); got != want {
+			t.Errorf("SanitizeLabelName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
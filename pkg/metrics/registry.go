@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry holds the latest metrics of each query and serves them on /metrics
+type Registry struct {
+	mu      sync.Mutex
+	queries map[string]*queryState
+}
+
+type queryState struct {
+	metrics     []Metric
+	success     bool
+	lastSuccess time.Time
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{queries: make(map[string]*queryState)}
+}
+
+// Update records the result of a run of the named query. On success the metrics of the query
+// are replaced; on failure the previous metrics are kept and the query is reported as failing.
+func (r *Registry) Update(query string, metrics []Metric, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.queries[query]
+	if !ok {
+		state = &queryState{}
+		r.queries[query] = state
+	}
+	state.success = err == nil
+	if err == nil {
+		state.metrics = metrics
+		state.lastSuccess = time.Now()
+	}
+}
+
+// Metrics returns the metrics of all queries together with the status of each query
+func (r *Registry) Metrics() []Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.queries))
+	for name := range r.queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	success := Metric{
+		Name: "ecs_log_viewer_query_success",
+		Help: "Whether the last run of the query succeeded (1) or failed (0)",
+	}
+	lastSuccess := Metric{
+		Name: "ecs_log_viewer_query_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run of the query",
+	}
+
+	var metrics []Metric
+	for _, name := range names {
+		state := r.queries[name]
+		metrics = append(metrics, state.metrics...)
+
+		labels := map[string]string{"query": name}
+		value := 0.0
+		if state.success {
+			value = 1
+		}
+		success.Samples = append(success.Samples, Sample{Labels: labels, Value: value})
+		if !state.lastSuccess.IsZero() {
+			lastSuccess.Samples = append(lastSuccess.Samples, Sample{Labels: labels, Value: float64(state.lastSuccess.Unix())})
+		}
+	}
+	return append(metrics, success, lastSuccess)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, r.Metrics()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Warning: failed to write metrics response: %v\n", err)
+	}
+}
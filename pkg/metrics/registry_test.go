@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Update("errors", []Metric{{Name: "app_errors", Samples: []Sample{{Value: 3}}}}, nil)
+	// a failed run keeps the previous values
+	registry.Update("errors", nil, errors.New("throttled"))
+
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	for _, want := range []string{
+		"app_errors 3\n",
+		`ecs_log_viewer_query_success{query="errors"} 0` + "\n",
+		`ecs_log_viewer_query_last_success_timestamp_seconds{query="errors"} `,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("response does not contain %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Package outputfile creates output files that are compressed by their extension
+// and splits large results into multiple files.
+package outputfile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Create creates the file at path. Writes are compressed with gzip when the path ends
+// with .gz and with zstd when it ends with .zst; other files are written as is.
+func Create(path string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return &compressedFile{Writer: gzip.NewWriter(file), file: file}, nil
+	case ".zst":
+		encoder, err := zstd.NewWriter(file)
+		if err != nil {
+			if closeErr := file.Close(); closeErr != nil {
+				return nil, fmt.Errorf("failed to create zstd encoder: %v (and failed to close %s: %v)", err, path, closeErr)
+			}
+			return nil, fmt.Errorf("failed to create zstd encoder: %v", err)
+		}
+		return &compressedFile{Writer: encoder, file: file}, nil
+	default:
+		return file, nil
+	}
+}
+
+// compressedFile flushes the compressor before closing the underlying file
+type compressedFile struct {
+	io.Writer
+	file *os.File
+}
+
+// Close flushes and closes the compressor, then closes the file. The first error is returned.
+func (f *compressedFile) Close() error {
+	var err error
+	if closer, ok := f.Writer.(io.Closer); ok {
+		err = closer.Close()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package outputfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+}
+
+func TestCreate_Plain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.jsonl")
+	writeFile(t, path, "hello\n")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("content = %q, want %q", data, "hello\n")
+	}
+}
+
+func TestCreate_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.jsonl.gz")
+	writeFile(t, path, "hello\n")
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("content = %q, want %q", data, "hello\n")
+	}
+}
+
+func TestCreate_Zstd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.csv.zst")
+	writeFile(t, path, "hello\n")
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	decoder, err := zstd.NewReader(file)
+	if err != nil {
+		t.Fatalf("zstd.NewReader() error: %v", err)
+	}
+	defer decoder.Close()
+	data, err := io.ReadAll(decoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("content = %q, want %q", data, "hello\n")
+	}
+}
@@ -0,0 +1,145 @@
+package outputfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// SplitOption controls how results are split into multiple files.
+// Only one of the options is expected to be set.
+type SplitOption struct {
+	// MaxBytes starts a new file once the field values of a file exceed this size
+	MaxBytes int64
+	// MaxEvents is the maximum number of events in a file
+	MaxEvents int
+	// Hourly puts the events of each hour in their own file
+	Hourly bool
+}
+
+// Enabled reports whether any split is configured
+func (o SplitOption) Enabled() bool {
+	return o.MaxBytes > 0 || o.MaxEvents > 0 || o.Hourly
+}
+
+// Part is a group of events to be written to one file
+type Part struct {
+	Path   string
+	Events [][]cwTypes.ResultField
+}
+
+// Split groups events into parts according to opt. The path of each part is the given path
+// with a suffix inserted before its extensions: the hour for hourly splits
+// (logs.jsonl.gz -> logs-2026-10-16T14.jsonl.gz) and a sequence number otherwise (logs-0001.jsonl.gz).
+// The order of the events is kept.
+func Split(path string, events [][]cwTypes.ResultField, opt SplitOption) ([]Part, error) {
+	if opt.Hourly {
+		return splitHourly(path, events)
+	}
+
+	var parts []Part
+	var current [][]cwTypes.ResultField
+	var size int64
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, Part{
+				Path:   withSuffix(path, fmt.Sprintf("%04d", len(parts)+1)),
+				Events: current,
+			})
+		}
+		current, size = nil, 0
+	}
+
+	for _, event := range events {
+		eventSize := eventSize(event)
+		if len(current) > 0 && ((opt.MaxEvents > 0 && len(current) >= opt.MaxEvents) ||
+			(opt.MaxBytes > 0 && size+eventSize > opt.MaxBytes)) {
+			flush()
+		}
+		current = append(current, event)
+		size += eventSize
+	}
+	flush()
+
+	return parts, nil
+}
+
+func splitHourly(path string, events [][]cwTypes.ResultField) ([]Part, error) {
+	var parts []Part
+	// index of the part of each hour
+	index := make(map[string]int)
+	for _, event := range events {
+		value, ok := cloudwatchclient.FieldValue(event, "@timestamp")
+		if !ok {
+			return nil, fmt.Errorf("splitting by hour requires the @timestamp field")
+		}
+		t, err := cloudwatchclient.ParseTimestamp(value)
+		if err != nil {
+			return nil, err
+		}
+
+		hour := t.UTC().Format("2006-01-02T15")
+		i, ok := index[hour]
+		if !ok {
+			i = len(parts)
+			index[hour] = i
+			parts = append(parts, Part{Path: withSuffix(path, hour)})
+		}
+		parts[i].Events = append(parts[i].Events, event)
+	}
+	return parts, nil
+}
+
+// eventSize approximates the written size of an event by the length of its values
+func eventSize(event []cwTypes.ResultField) int64 {
+	var size int64
+	for _, field := range event {
+		if field.Value != nil {
+			size += int64(len(*field.Value))
+		}
+	}
+	return size
+}
+
+// withSuffix inserts "-suffix" before the extensions of the file name in path
+func withSuffix(path, suffix string) string {
+	dir, name := filepath.Split(path)
+	base, ext := name, ""
+	if i := strings.Index(name, "."); i > 0 {
+		base, ext = name[:i], name[i:]
+	}
+	return dir + base + "-" + suffix + ext
+}
+
+// ParseSize parses a size such as 500000, 100KB, 100MB or 1GB into bytes
+func ParseSize(s string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return n * multiplier, nil
+}
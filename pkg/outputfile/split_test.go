@@ -0,0 +1,124 @@
+package outputfile
+
+import (
+	"reflect"
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func event(ts, message string) []cwTypes.ResultField {
+	return []cwTypes.ResultField{
+		{Field: stringPtr("@timestamp"), Value: stringPtr(ts)},
+		{Field: stringPtr("@message"), Value: stringPtr(message)},
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func partPaths(parts []Part) []string {
+	var paths []string
+	for _, part := range parts {
+		paths = append(paths, part.Path)
+	}
+	return paths
+}
+
+func TestSplit_Hourly(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		event("2026-10-16 14:59:00.000", "a"),
+		event("2026-10-16 15:00:00.000", "b"),
+		event("2026-10-16 14:01:00.000", "c"),
+	}
+
+	parts, err := Split("out/logs.jsonl.gz", events, SplitOption{Hourly: true})
+	if err != nil {
+		t.Fatalf("Split() error: %v", err)
+	}
+
+	want := []string{"out/logs-2026-10-16T14.jsonl.gz", "out/logs-2026-10-16T15.jsonl.gz"}
+	if got := partPaths(parts); !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	if len(parts[0].Events) != 2 || len(parts[1].Events) != 1 {
+		t.Errorf("unexpected event counts: %d, %d", len(parts[0].Events), len(parts[1].Events))
+	}
+}
+
+func TestSplit_HourlyWithoutTimestamp(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{{Field: stringPtr("@message"), Value: stringPtr("a")}},
+	}
+	if _, err := Split("logs.csv", events, SplitOption{Hourly: true}); err == nil {
+		t.Error("expected an error without @timestamp")
+	}
+}
+
+func TestSplit_MaxEvents(t *testing.T) {
+	var events [][]cwTypes.ResultField
+	for i := 0; i < 5; i++ {
+		events = append(events, event("2026-10-16 14:00:00.000", "a"))
+	}
+
+	parts, err := Split("logs.csv", events, SplitOption{MaxEvents: 2})
+	if err != nil {
+		t.Fatalf("Split() error: %v", err)
+	}
+
+	want := []string{"logs-0001.csv", "logs-0002.csv", "logs-0003.csv"}
+	if got := partPaths(parts); !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	if len(parts[2].Events) != 1 {
+		t.Errorf("last part has %d events, want 1", len(parts[2].Events))
+	}
+}
+
+func TestSplit_MaxBytes(t *testing.T) {
+	// each event is 23 bytes of timestamp and 7 bytes of message
+	events := [][]cwTypes.ResultField{
+		event("2026-10-16 14:00:00.000", "message"),
+		event("2026-10-16 14:00:00.000", "message"),
+		event("2026-10-16 14:00:00.000", "message"),
+	}
+
+	parts, err := Split("logs", events, SplitOption{MaxBytes: 60})
+	if err != nil {
+		t.Fatalf("Split() error: %v", err)
+	}
+
+	want := []string{"logs-0001", "logs-0002"}
+	if got := partPaths(parts); !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"500", 500},
+		{"100KB", 100 << 10},
+		{"100mb", 100 << 20},
+		{"1 GB", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseSize(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1", "ten"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q) expected error", input)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package patterns
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// Cluster is a group of log messages sharing the same template
+type Cluster struct {
+	Template  string
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Sample is the first message seen for the template
+	Sample string
+}
+
+// ClusterEvents groups log events by the normalized template of their @message.
+// The clusters are sorted by frequency, most frequent first.
+func ClusterEvents(events [][]cwTypes.ResultField) []Cluster {
+	byTemplate := make(map[string]*Cluster)
+
+	for _, event := range events {
+		message, ok := cloudwatchclient.FieldValue(event, "@message")
+		if !ok {
+			continue
+		}
+		var timestamp time.Time
+		if value, ok := cloudwatchclient.FieldValue(event, "@timestamp"); ok {
+			timestamp, _ = cloudwatchclient.ParseTimestamp(value)
+		}
+
+		template := Normalize(message)
+		cluster, ok := byTemplate[template]
+		if !ok {
+			cluster = &Cluster{Template: template, Sample: message, FirstSeen: timestamp, LastSeen: timestamp}
+			byTemplate[template] = cluster
+		}
+
+		cluster.Count++
+		if !timestamp.IsZero() {
+			if cluster.FirstSeen.IsZero() || timestamp.Before(cluster.FirstSeen) {
+				cluster.FirstSeen = timestamp
+				cluster.Sample = message
+			}
+			if timestamp.After(cluster.LastSeen) {
+				cluster.LastSeen = timestamp
+			}
+		}
+	}
+
+	clusters := make([]Cluster, 0, len(byTemplate))
+	for _, cluster := range byTemplate {
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].Template < clusters[j].Template
+	})
+	return clusters
+}
+
+// RedactClusters replaces sensitive values of the templates and samples in place with redact,
+// e.g. the Redact method of a redact.Redactor
+func RedactClusters(clusters []Cluster, redact func(string) string) {
+	for i := range clusters {
+		clusters[i].Template = redact(clusters[i].Template)
+		clusters[i].Sample = redact(clusters[i].Sample)
+	}
+}
+
+// WriteClusters writes a frequency report of the clusters as an aligned table
+func WriteClusters(w io.Writer, clusters []Cluster) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "COUNT\tFIRST SEEN\tLAST SEEN\tTEMPLATE"); err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		if _, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n",
+			cluster.Count,
+			formatTime(cluster.FirstSeen),
+			formatTime(cluster.LastSeen),
+			cloudwatchclient.SingleLine(cluster.Template),
+		); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(tw, "\t\t\t  e.g. %s\n", cloudwatchclient.SingleLine(cluster.Sample)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
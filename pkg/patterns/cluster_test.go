@@ -0,0 +1,92 @@
+package patterns
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/redact"
+)
+
+// ptr is a helper to get pointer to a string.
+func ptr(s string) *string {
+	return &s
+}
+
+func event(timestamp, message string) []cwTypes.ResultField {
+	return []cwTypes.ResultField{
+		{Field: ptr("@timestamp"), Value: ptr(timestamp)},
+		{Field: ptr("@message"), Value: ptr(message)},
+	}
+}
+
+func TestClusterEvents(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		event("2025-02-16 10:00:02.000", "timeout after 30s calling 10.0.0.1"),
+		event("2025-02-16 10:00:00.000", "timeout after 10s calling 10.0.0.2"),
+		event("2025-02-16 10:00:01.000", "user 42 logged in"),
+		event("2025-02-16 10:00:03.000", "timeout after 5s calling 10.0.0.3"),
+	}
+
+	clusters := ClusterEvents(events)
+
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d: %+v", len(clusters), clusters)
+	}
+
+	top := clusters[0]
+	if top.Template != "timeout after <NUM>s calling <IP>" {
+		t.Errorf("Unexpected template %q", top.Template)
+	}
+	if top.Count != 3 {
+		t.Errorf("Expected count 3, got %d", top.Count)
+	}
+	if want := time.Date(2025, 2, 16, 10, 0, 0, 0, time.UTC); !top.FirstSeen.Equal(want) {
+		t.Errorf("Expected first seen %v, got %v", want, top.FirstSeen)
+	}
+	if want := time.Date(2025, 2, 16, 10, 0, 3, 0, time.UTC); !top.LastSeen.Equal(want) {
+		t.Errorf("Expected last seen %v, got %v", want, top.LastSeen)
+	}
+	if top.Sample != "timeout after 10s calling 10.0.0.2" {
+		t.Errorf("Expected sample to be the earliest message, got %q", top.Sample)
+	}
+}
+
+func TestRedactClusters(t *testing.T) {
+	clusters := ClusterEvents([][]cwTypes.ResultField{
+		event("2025-02-16 10:00:00.000", "login failed for alice@example.com"),
+	})
+
+	RedactClusters(clusters, redact.New(redact.DefaultRules()).Redact)
+
+	if clusters[0].Template != "login failed for <EMAIL_1>" || clusters[0].Sample != "login failed for <EMAIL_1>" {
+		t.Errorf("Expected the email to be redacted, got %+v", clusters[0])
+	}
+}
+
+func TestWriteClusters_ControlCharacters(t *testing.T) {
+	clusters := []Cluster{{
+		Template: "java.lang.IllegalStateException\n\tat <NUM>",
+		Count:    2,
+		Sample:   "java.lang.IllegalStateException\r\n\tat Main.run",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteClusters(&buf, clusters); err != nil {
+		t.Fatalf("WriteClusters() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header, a cluster and a sample line, got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[1], "java.lang.IllegalStateException⏎ at <NUM>") {
+		t.Errorf("Unexpected cluster line %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "e.g. java.lang.IllegalStateException⏎ at Main.run") {
+		t.Errorf("Unexpected sample line %q", lines[2])
+	}
+}
@@ -0,0 +1,173 @@
+package patterns
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// ChangeKind describes how a pattern changed between two windows
+type ChangeKind string
+
+// Kinds of pattern changes
+const (
+	ChangeNew       ChangeKind = "new"
+	ChangeGone      ChangeKind = "gone"
+	ChangeIncreased ChangeKind = "increased"
+	ChangeDecreased ChangeKind = "decreased"
+)
+
+// changeOrder is the order in which kinds of changes are reported
+var changeOrder = map[ChangeKind]int{
+	ChangeNew:       0,
+	ChangeIncreased: 1,
+	ChangeDecreased: 2,
+	ChangeGone:      3,
+}
+
+// Change is a pattern whose frequency differs between the base and the target window
+type Change struct {
+	Kind        ChangeKind
+	Template    string
+	BaseCount   int
+	TargetCount int
+	// Ratio is the change of the per-second rate from base to target. It is +Inf for new
+	// patterns and 0 for gone patterns.
+	Ratio  float64
+	Sample string
+}
+
+// CompareOption controls which changes are reported by Compare
+type CompareOption struct {
+	BaseDuration   time.Duration
+	TargetDuration time.Duration
+	// Threshold is the minimum rate ratio (or its inverse) for a change to be significant
+	Threshold float64
+	// MinCount ignores patterns seen less often than this in both windows
+	MinCount int
+}
+
+// ValidateThreshold checks that a threshold can tell increased from decreased patterns.
+// A ratio of 1 or less would report every pattern as changed.
+func ValidateThreshold(threshold float64) error {
+	if !(threshold > 1) {
+		return fmt.Errorf("threshold must be greater than 1, got %g", threshold)
+	}
+	return nil
+}
+
+// Compare reports patterns that are new, gone, or whose rate changed by at least the threshold
+// between the base and target clusters. Rates are used instead of counts so that windows of
+// different lengths can be compared.
+func Compare(base, target []Cluster, opt CompareOption) []Change {
+	baseByTemplate := make(map[string]Cluster, len(base))
+	for _, c := range base {
+		baseByTemplate[c.Template] = c
+	}
+	targetByTemplate := make(map[string]Cluster, len(target))
+	for _, c := range target {
+		targetByTemplate[c.Template] = c
+	}
+
+	var changes []Change
+	for _, t := range target {
+		b, ok := baseByTemplate[t.Template]
+		if t.Count < opt.MinCount && b.Count < opt.MinCount {
+			continue
+		}
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeNew, Template: t.Template, TargetCount: t.Count, Ratio: math.Inf(1), Sample: t.Sample})
+			continue
+		}
+
+		ratio := rate(t.Count, opt.TargetDuration) / rate(b.Count, opt.BaseDuration)
+		change := Change{Template: t.Template, BaseCount: b.Count, TargetCount: t.Count, Ratio: ratio, Sample: t.Sample}
+		switch {
+		case ratio >= opt.Threshold:
+			change.Kind = ChangeIncreased
+		case ratio <= 1/opt.Threshold:
+			change.Kind = ChangeDecreased
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for _, b := range base {
+		if _, ok := targetByTemplate[b.Template]; ok || b.Count < opt.MinCount {
+			continue
+		}
+		changes = append(changes, Change{Kind: ChangeGone, Template: b.Template, BaseCount: b.Count, Sample: b.Sample})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changeOrder[changes[i].Kind] < changeOrder[changes[j].Kind]
+		}
+		switch changes[i].Kind {
+		case ChangeNew:
+			return changes[i].TargetCount > changes[j].TargetCount
+		case ChangeGone:
+			return changes[i].BaseCount > changes[j].BaseCount
+		case ChangeIncreased:
+			return changes[i].Ratio > changes[j].Ratio
+		default:
+			return changes[i].Ratio < changes[j].Ratio
+		}
+	})
+	return changes
+}
+
+func rate(count int, d time.Duration) float64 {
+	if d <= 0 {
+		return float64(count)
+	}
+	return float64(count) / d.Seconds()
+}
+
+// RedactChanges replaces sensitive values of the templates and samples in place with redact
+func RedactChanges(changes []Change, redact func(string) string) {
+	for i := range changes {
+		changes[i].Template = redact(changes[i].Template)
+		changes[i].Sample = redact(changes[i].Sample)
+	}
+}
+
+// WriteChanges writes the changes as an aligned table
+func WriteChanges(w io.Writer, changes []Change) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "CHANGE\tBEFORE\tAFTER\tRATIO\tTEMPLATE"); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if _, err := fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n",
+			change.Kind,
+			change.BaseCount,
+			change.TargetCount,
+			formatRatio(change.Ratio),
+			cloudwatchclient.SingleLine(change.Template),
+		); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(tw, "\t\t\t\t  e.g. %s\n", cloudwatchclient.SingleLine(change.Sample)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func formatRatio(ratio float64) string {
+	switch {
+	case math.IsInf(ratio, 1):
+		return "-"
+	case ratio == 0:
+		return "-"
+	default:
+		return fmt.Sprintf("x%.2f", ratio)
+	}
+}
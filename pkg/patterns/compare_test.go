@@ -0,0 +1,90 @@
+package patterns
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/redact"
+)
+
+func TestCompare(t *testing.T) {
+	base := []Cluster{
+		{Template: "request ok", Count: 100},
+		{Template: "cache miss", Count: 10},
+		{Template: "retrying", Count: 40},
+		{Template: "legacy path used", Count: 8},
+		{Template: "rare", Count: 1},
+	}
+	target := []Cluster{
+		{Template: "request ok", Count: 110},
+		{Template: "cache miss", Count: 50},
+		{Template: "retrying", Count: 5},
+		{Template: "NullPointerException", Count: 12},
+	}
+
+	changes := Compare(base, target, CompareOption{
+		BaseDuration:   time.Hour,
+		TargetDuration: time.Hour,
+		Threshold:      2,
+		MinCount:       2,
+	})
+
+	want := []struct {
+		kind     ChangeKind
+		template string
+	}{
+		{ChangeNew, "NullPointerException"},
+		{ChangeIncreased, "cache miss"},
+		{ChangeDecreased, "retrying"},
+		{ChangeGone, "legacy path used"},
+	}
+
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Kind != w.kind || changes[i].Template != w.template {
+			t.Errorf("change %d = %s %q, want %s %q", i, changes[i].Kind, changes[i].Template, w.kind, w.template)
+		}
+	}
+}
+
+// TestCompare_DifferentWindowLengths tests that counts are compared as rates.
+func TestCompare_DifferentWindowLengths(t *testing.T) {
+	base := []Cluster{{Template: "request ok", Count: 200}}
+	target := []Cluster{{Template: "request ok", Count: 100}}
+
+	changes := Compare(base, target, CompareOption{
+		BaseDuration:   2 * time.Hour,
+		TargetDuration: time.Hour,
+		Threshold:      2,
+	})
+
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes for equal rates, got %+v", changes)
+	}
+}
+
+func TestValidateThreshold(t *testing.T) {
+	for _, threshold := range []float64{1.5, 2, 10} {
+		if err := ValidateThreshold(threshold); err != nil {
+			t.Errorf("ValidateThreshold(%g) error = %v", threshold, err)
+		}
+	}
+	for _, threshold := range []float64{1, 0.5, 0, -2, math.NaN()} {
+		if err := ValidateThreshold(threshold); err == nil {
+			t.Errorf("ValidateThreshold(%g) expected an error", threshold)
+		}
+	}
+}
+
+func TestRedactChanges(t *testing.T) {
+	changes := []Change{{Kind: ChangeNew, Template: "token Bearer abc.def", Sample: "token Bearer abc.def"}}
+
+	RedactChanges(changes, redact.New(redact.DefaultRules()).Redact)
+
+	if changes[0].Template != "token Bearer <TOKEN_1>" || changes[0].Sample != "token Bearer <TOKEN_1>" {
+		t.Errorf("Expected the token to be redacted, got %+v", changes[0])
+	}
+}
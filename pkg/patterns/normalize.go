@@ -0,0 +1,62 @@
+package patterns
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tokenRule replaces variable tokens matching pattern with a placeholder
+type tokenRule struct {
+	pattern     *regexp.Regexp
+	placeholder string
+	// accept optionally rejects matches that should be kept as they are
+	accept func(match string) bool
+}
+
+// tokenRules are applied in order, so more specific tokens must come first
+var tokenRules = []tokenRule{
+	{
+		pattern:     regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`),
+		placeholder: "<UUID>",
+	},
+	{
+		pattern:     regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+		placeholder: "<TIME>",
+	},
+	{
+		pattern:     regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`),
+		placeholder: "<IP>",
+	},
+	{
+		pattern:     regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|[0-9a-fA-F]{8,})\b`),
+		placeholder: "<HEX>",
+		// Plain words such as "deadbeef" or "accepted" are not IDs
+		accept: func(match string) bool {
+			return strings.HasPrefix(match, "0x") || strings.ContainsAny(match, "0123456789")
+		},
+	},
+	{
+		pattern:     regexp.MustCompile(`\b-?\d+(?:\.\d+)?`),
+		placeholder: "<NUM>",
+	},
+}
+
+// Normalize replaces variable tokens in a log message (UUIDs, timestamps, IP addresses,
+// hex IDs and numbers) with placeholders, so that messages produced by the same
+// log statement share the same template.
+func Normalize(message string) string {
+	normalized := strings.TrimSpace(message)
+	for _, rule := range tokenRules {
+		if rule.accept == nil {
+			normalized = rule.pattern.ReplaceAllLiteralString(normalized, rule.placeholder)
+			continue
+		}
+		normalized = rule.pattern.ReplaceAllStringFunc(normalized, func(match string) string {
+			if rule.accept(match) {
+				return rule.placeholder
+			}
+			return match
+		})
+	}
+	return normalized
+}
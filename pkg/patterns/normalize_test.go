@@ -0,0 +1,50 @@
+package patterns
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "numbers",
+			message: "request took 123ms with 2 retries",
+			want:    "request took <NUM>ms with <NUM> retries",
+		},
+		{
+			name:    "uuid",
+			message: "user 3f2504e0-4f89-11d3-9a0c-0305e82c3301 logged in",
+			want:    "user <UUID> logged in",
+		},
+		{
+			name:    "ip address with port",
+			message: "connection from 10.0.12.34:54321 refused",
+			want:    "connection from <IP> refused",
+		},
+		{
+			name:    "hex ids",
+			message: "task 5e7d0a3c9f1b4e2a stopped at 0x1f",
+			want:    "task <HEX> stopped at <HEX>",
+		},
+		{
+			name:    "hex-like words are kept",
+			message: "request accepted",
+			want:    "request accepted",
+		},
+		{
+			name:    "timestamp",
+			message: "job scheduled at 2025-02-16T10:00:00.123Z",
+			want:    "job scheduled at <TIME>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.message); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Rule detects one kind of sensitive value
+type Rule struct {
+	// Name is used in the placeholder, e.g. "email" produces <EMAIL_1>
+	Name string
+	// Pattern matches the sensitive value. If it has a capture group, only the first group is replaced.
+	Pattern *regexp.Regexp
+	// valid optionally rejects matches, e.g. digit sequences that fail the Luhn check
+	valid func(value string) bool
+}
+
+// NewRule creates a user-defined rule from a regular expression
+func NewRule(name, pattern string) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid pattern for redaction rule %q: %v", name, err)
+	}
+	return Rule{Name: name, Pattern: re}, nil
+}
+
+// DefaultRules returns the built-in detectors.
+// They are ordered so that more specific values are detected first.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "aws_access_key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)},
+		{Name: "aws_secret_key", Pattern: regexp.MustCompile(`(?i)(?:aws_?secret_?access_?key|secret_?access_?key)["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+		{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+		{Name: "token", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)},
+		{Name: "email", Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+		{Name: "credit_card", Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhnValid},
+		{Name: "ip", Pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+	}
+}
+
+// skippedFields are never redacted since they contain no user data
+var skippedFields = map[string]bool{
+	"@timestamp":     true,
+	"@ingestionTime": true,
+	"@ptr":           true,
+}
+
+// Redactor replaces sensitive values with placeholders.
+// The same value is always replaced with the same placeholder, so that redacted
+// output can still be correlated (e.g. all requests of <EMAIL_3>).
+type Redactor struct {
+	rules        []Rule
+	placeholders map[string]string
+	counts       map[string]int
+}
+
+// New creates a Redactor applying the rules in order
+func New(rules []Rule) *Redactor {
+	return &Redactor{
+		rules:        rules,
+		placeholders: make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// Redact returns s with all sensitive values replaced
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		s = r.apply(rule, s)
+	}
+	return s
+}
+
+func (r *Redactor) apply(rule Rule, s string) string {
+	matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// redact only the first capture group when there is one
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		value := s[start:end]
+		if rule.valid != nil && !rule.valid(value) {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.placeholder(rule.Name, value))
+		last = end
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+// placeholder returns the stable placeholder for a value detected by the named rule
+func (r *Redactor) placeholder(name, value string) string {
+	key := name + "\x00" + value
+	if p, ok := r.placeholders[key]; ok {
+		return p
+	}
+	r.counts[name]++
+	p := fmt.Sprintf("<%s_%d>", strings.ToUpper(name), r.counts[name])
+	r.placeholders[key] = p
+	return p
+}
+
+// RedactEvents redacts the values of all fields of the events in place, except timestamps
+func (r *Redactor) RedactEvents(events [][]cwTypes.ResultField) {
+	for _, event := range events {
+		for i, field := range event {
+			if field.Value == nil || skippedFields[aws.ToString(field.Field)] {
+				continue
+			}
+			event[i].Value = aws.String(r.Redact(*field.Value))
+		}
+	}
+}
+
+// luhnValid reports whether the digits in value pass the Luhn checksum used by card numbers
+func luhnValid(value string) bool {
+	sum := 0
+	double := false
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -15,8 +15,8 @@ type selectorItem interface {
 	Label() string
 }
 
-// selectByLabels displays a selection prompt with the given labels and returns the selected label.
-func selectByLabels(labels []string, prompt string) (string, error) {
+// askOne runs a survey prompt, rendering it on the terminal even when stdout is redirected.
+func askOne(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
 	var out terminal.FileWriter
 	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
 		defer func() {
@@ -29,19 +29,34 @@ func selectByLabels(labels []string, prompt string) (string, error) {
 		out = os.Stdout
 	}
 
+	opts = append(opts, survey.WithStdio(os.Stdin, out, os.Stderr))
+	return survey.AskOne(p, response, opts...)
+}
+
+// selectByLabels displays a selection prompt with the given labels and returns the selected label.
+func selectByLabels(labels []string, prompt string) (string, error) {
 	option := &survey.Select{
 		Message: prompt,
 		Options: labels,
 	}
 
 	var answer string
-	if err := survey.AskOne(option, &answer, survey.WithStdio(os.Stdin, out, os.Stderr)); err != nil {
+	if err := askOne(option, &answer); err != nil {
 		return "", err
 	}
 
 	return answer, nil
 }
 
+// InputText prompts the user for a single line of text and returns it.
+func InputText(prompt string) (string, error) {
+	var answer string
+	if err := askOne(&survey.Input{Message: prompt}, &answer); err != nil {
+		return "", err
+	}
+	return answer, nil
+}
+
 // SelectItem presents a list of items to the user and returns the selected item.
 func SelectItem[T selectorItem](items []T, prompt string) (T, error) {
 	labels := make([]string, len(items))
@@ -65,6 +80,36 @@ func SelectItem[T selectorItem](items []T, prompt string) (T, error) {
 	return zero, fmt.Errorf("selected answer %q not found in items", answer)
 }
 
+// SelectMultiple presents a list of items to the user and returns the checked items in their original order.
+func SelectMultiple[T selectorItem](items []T, prompt string) ([]T, error) {
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label()
+	}
+
+	var answers []string
+	option := &survey.MultiSelect{
+		Message:  prompt,
+		Options:  labels,
+		PageSize: 15,
+	}
+	if err := askOne(option, &answers, survey.WithValidator(survey.MinItems(1))); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(answers))
+	for _, answer := range answers {
+		selected[answer] = true
+	}
+	var result []T
+	for i, item := range items {
+		if selected[labels[i]] {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
 // SelectContainerDefinition presents a list of container definitions to the user and returns the selected one.
 func SelectContainerDefinition(containerDefinitions []types.ContainerDefinition, prompt string) (types.ContainerDefinition, error) {
 	labels := make([]string, len(containerDefinitions))
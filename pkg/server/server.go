@@ -0,0 +1,334 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/redact"
+)
+
+// Backend provides the task definitions, containers and logs served by the Server
+type Backend interface {
+	// TaskDefinitionFamilies lists the task definition families that can be selected
+	TaskDefinitionFamilies() ([]string, error)
+	// Containers lists the containers of the latest revision of a task definition family
+	Containers(family string) ([]string, error)
+	// QueryLogs queries the logs of a container
+	QueryLogs(family, container string, fields []string, filter string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error)
+}
+
+// Options configures a Server
+type Options struct {
+	// AllowedHosts are the host names the UI may be reached by besides localhost and loopback
+	// addresses. Requests for other hosts are rejected to protect against DNS rebinding.
+	AllowedHosts []string
+	// Redactor, when set, redacts the events of every query before they are served
+	Redactor *redact.Redactor
+}
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// fieldName matches the field names accepted in the fields parameter, e.g. @timestamp or detail.user_id
+var fieldName = regexp.MustCompile(`^@?[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// queryKey identifies a query whose results are cached for paging and searching
+type queryKey struct {
+	family    string
+	container string
+	fields    string
+	filter    string
+	duration  time.Duration
+}
+
+// queryResult is a cached query result
+type queryResult struct {
+	fields    []string
+	rows      [][]string
+	startTime time.Time
+	endTime   time.Time
+}
+
+// Server serves a small web UI for browsing logs, backed by a Backend
+type Server struct {
+	backend      Backend
+	mux          *http.ServeMux
+	allowedHosts []string
+	redactor     *redact.Redactor
+
+	mu        sync.Mutex
+	lastKey   queryKey
+	lastQuery *queryResult
+}
+
+// New creates a new Server
+func New(backend Backend, opt Options) *Server {
+	s := &Server{
+		backend:      backend,
+		mux:          http.NewServeMux(),
+		allowedHosts: opt.AllowedHosts,
+		redactor:     opt.Redactor,
+	}
+
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+	s.mux.Handle("/", http.FileServer(http.FS(static)))
+	s.mux.HandleFunc("/api/taskdefs", s.handleTaskDefs)
+	s.mux.HandleFunc("/api/containers", s.handleContainers)
+	s.mux.HandleFunc("/api/logs", s.handleLogs)
+	return s
+}
+
+// ServeHTTP implements http.Handler. Requests for a host that is not allowed, or sent by
+// pages of another origin, are rejected.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.hostAllowed(r.Host) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("host not allowed: %s", r.Host))
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !s.hostAllowed(u.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin not allowed: %s", origin))
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// hostAllowed reports whether host, with or without a port, is localhost, a loopback address or an allowed host
+func (s *Server) hostAllowed(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, allowed := range s.allowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleTaskDefs(w http.ResponseWriter, r *http.Request) {
+	families, err := s.backend.TaskDefinitionFamilies()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to list task definition families: %v", err))
+		return
+	}
+	writeJSON(w, families)
+}
+
+func (s *Server) handleContainers(w http.ResponseWriter, r *http.Request) {
+	family := r.URL.Query().Get("taskdef")
+	if family == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("taskdef is required"))
+		return
+	}
+
+	containers, err := s.backend.Containers(family)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to list containers: %v", err))
+		return
+	}
+	writeJSON(w, containers)
+}
+
+// logsResponse is a page of log events
+type logsResponse struct {
+	Fields    []string   `json:"fields"`
+	Rows      [][]string `json:"rows"`
+	Total     int        `json:"total"`
+	Page      int        `json:"page"`
+	PageSize  int        `json:"pageSize"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   time.Time  `json:"endTime"`
+}
+
+// handleLogs returns a page of the logs of a container, optionally narrowed down by a search term.
+// Query results are cached so that paging and searching do not run the query again;
+// pass refresh=1 to run it again.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	key := queryKey{
+		family:    params.Get("taskdef"),
+		container: params.Get("container"),
+		fields:    params.Get("fields"),
+		filter:    params.Get("filter"),
+	}
+	if key.family == "" || key.container == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("taskdef and container are required"))
+		return
+	}
+	if key.fields == "" {
+		key.fields = "@timestamp,@message"
+	}
+	// fields are part of the query, so only plain field names are accepted
+	for _, field := range strings.Split(key.fields, ",") {
+		if !fieldName.MatchString(field) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid field: %q", field))
+			return
+		}
+	}
+
+	var err error
+	key.duration, err = time.ParseDuration(defaultString(params.Get("duration"), "1h"))
+	if err != nil || key.duration <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid duration: %q", params.Get("duration")))
+		return
+	}
+
+	page, err := strconv.Atoi(defaultString(params.Get("page"), "1"))
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid page: %q", params.Get("page")))
+		return
+	}
+	pageSize, err := strconv.Atoi(defaultString(params.Get("pageSize"), strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid pageSize: %q", params.Get("pageSize")))
+		return
+	}
+
+	result, err := s.query(key, params.Get("refresh") == "1")
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to query logs: %v", err))
+		return
+	}
+
+	rows := search(result.rows, params.Get("search"))
+	writeJSON(w, logsResponse{
+		Fields:    result.fields,
+		Rows:      paginate(rows, page, pageSize),
+		Total:     len(rows),
+		Page:      page,
+		PageSize:  pageSize,
+		StartTime: result.startTime,
+		EndTime:   result.endTime,
+	})
+}
+
+// query returns the cached result for key, running the query if needed.
+// The lock is not held while the query runs, so other requests are not blocked by it.
+func (s *Server) query(key queryKey, refresh bool) (*queryResult, error) {
+	s.mu.Lock()
+	cached := s.lastQuery
+	if s.lastKey != key {
+		cached = nil
+	}
+	s.mu.Unlock()
+
+	if !refresh && cached != nil {
+		return cached, nil
+	}
+
+	fields := strings.Split(key.fields, ",")
+	endTime := time.Now()
+	startTime := endTime.Add(-key.duration)
+
+	events, err := s.backend.QueryLogs(key.family, key.container, fields, key.filter, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the redactor is shared by the queries of all requests and is not safe for concurrent use
+	if s.redactor != nil {
+		s.redactor.RedactEvents(events)
+	}
+
+	result := &queryResult{fields: fields, startTime: startTime, endTime: endTime}
+	for _, event := range events {
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			row[i], _ = cloudwatchclient.FieldValue(event, field)
+		}
+		result.rows = append(result.rows, row)
+	}
+
+	s.lastKey = key
+	s.lastQuery = result
+	return result, nil
+}
+
+// search returns the rows containing term in any column, ignoring case
+func search(rows [][]string, term string) [][]string {
+	if term == "" {
+		return rows
+	}
+	term = strings.ToLower(term)
+
+	var matched [][]string
+	for _, row := range rows {
+		for _, value := range row {
+			if strings.Contains(strings.ToLower(value), term) {
+				matched = append(matched, row)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// paginate returns the rows of a 1-based page
+func paginate(rows [][]string, page, pageSize int) [][]string {
+	start := (page - 1) * pageSize
+	if start >= len(rows) {
+		return [][]string{}
+	}
+	end := start + pageSize
+	if end > len(rows) {
+		end = len(rows)
+	}
+	return rows[start:end]
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Warning: failed to write response: %v\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
+		log.Printf("Warning: failed to write response: %v\n", err)
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/redact"
+)
+
+// ptr is a helper to get pointer to a string.
+func ptr(s string) *string {
+	return &s
+}
+
+// fakeBackend returns fixed log events and counts the queries
+type fakeBackend struct {
+	events  [][]cwTypes.ResultField
+	queries int
+}
+
+func (b *fakeBackend) TaskDefinitionFamilies() ([]string, error) {
+	return []string{"web"}, nil
+}
+
+func (b *fakeBackend) Containers(family string) ([]string, error) {
+	return []string{"app"}, nil
+}
+
+func (b *fakeBackend) QueryLogs(family, container string, fields []string, filter string, startTime, endTime time.Time) ([][]cwTypes.ResultField, error) {
+	b.queries++
+	return b.events, nil
+}
+
+// newRequest creates a request for the UI at localhost
+func newRequest(target string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Host = "localhost:8080"
+	return r
+}
+
+func getLogs(t *testing.T, s *Server, query string) logsResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, newRequest("/api/logs?"+query))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp logsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+// TestServer_Logs tests paging and searching of cached query results.
+func TestServer_Logs(t *testing.T) {
+	backend := &fakeBackend{}
+	for _, message := range []string{"started", "ERROR failed", "request ok", "error again", "stopped"} {
+		backend.events = append(backend.events, []cwTypes.ResultField{
+			{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")},
+			{Field: ptr("@message"), Value: ptr(message)},
+		})
+	}
+	s := New(backend, Options{})
+
+	resp := getLogs(t, s, "taskdef=web&container=app&page=2&pageSize=2")
+	if resp.Total != 5 || len(resp.Rows) != 2 || resp.Rows[0][1] != "request ok" {
+		t.Errorf("Unexpected second page: %+v", resp)
+	}
+
+	resp = getLogs(t, s, "taskdef=web&container=app&search=error")
+	if resp.Total != 2 || resp.Rows[1][1] != "error again" {
+		t.Errorf("Unexpected search result: %+v", resp)
+	}
+
+	if backend.queries != 1 {
+		t.Errorf("Expected the query to run once, got %d", backend.queries)
+	}
+
+	getLogs(t, s, "taskdef=web&container=app&refresh=1")
+	if backend.queries != 2 {
+		t.Errorf("Expected refresh to run the query again, got %d queries", backend.queries)
+	}
+}
+
+// TestServer_Redact tests that the served events are redacted.
+func TestServer_Redact(t *testing.T) {
+	backend := &fakeBackend{events: [][]cwTypes.ResultField{{
+		{Field: ptr("@timestamp"), Value: ptr("2025-02-16 10:00:00.000")},
+		{Field: ptr("@message"), Value: ptr("login by alice@example.com")},
+	}}}
+	s := New(backend, Options{Redactor: redact.New(redact.DefaultRules())})
+
+	resp := getLogs(t, s, "taskdef=web&container=app")
+	if len(resp.Rows) != 1 || resp.Rows[0][0] != "2025-02-16 10:00:00.000" || resp.Rows[0][1] != "login by <EMAIL_1>" {
+		t.Errorf("Expected the email to be redacted, got %+v", resp.Rows)
+	}
+}
+
+// TestServer_LogsRequiresContainer tests that a container must be selected.
+func TestServer_LogsRequiresContainer(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(&fakeBackend{}, Options{}).ServeHTTP(rec, newRequest("/api/logs?taskdef=web"))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+// TestServer_Index tests that the web UI is served.
+func TestServer_Index(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(&fakeBackend{}, Options{}).ServeHTTP(rec, newRequest("/"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "ECS Log Viewer") {
+		t.Errorf("Expected the web UI, got %d: %q", rec.Code, rec.Body.String())
+	}
+}
+
+// TestServer_InvalidFields tests that fields other than plain field names are rejected.
+func TestServer_InvalidFields(t *testing.T) {
+	backend := &fakeBackend{}
+	s := New(backend, Options{})
+	for _, fields := range []string{"@message | stats count(*)", "@message,", "detail..id"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, newRequest("/api/logs?taskdef=web&container=app&fields="+url.QueryEscape(fields)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for fields %q, got %d", fields, rec.Code)
+		}
+	}
+	if backend.queries != 0 {
+		t.Errorf("Expected no queries, got %d", backend.queries)
+	}
+
+	getLogs(t, s, "taskdef=web&container=app&fields="+url.QueryEscape("@timestamp,detail.user_id"))
+}
+
+// TestServer_Host tests that requests for other hosts or from other origins are rejected.
+func TestServer_Host(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   int
+	}{
+		{name: "localhost", host: "localhost:8080", want: http.StatusOK},
+		{name: "IPv4 loopback", host: "127.0.0.1:8080", want: http.StatusOK},
+		{name: "IPv6 loopback", host: "[::1]:8080", want: http.StatusOK},
+		{name: "allowed host", host: "logs.internal:8080", want: http.StatusOK},
+		{name: "same origin", host: "localhost:8080", origin: "http://localhost:8080", want: http.StatusOK},
+		{name: "other host", host: "attacker.example:8080", want: http.StatusForbidden},
+		{name: "other origin", host: "localhost:8080", origin: "http://attacker.example", want: http.StatusForbidden},
+	}
+
+	s := New(&fakeBackend{}, Options{AllowedHosts: []string{"logs.internal"}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/taskdefs", nil)
+			r.Host = tt.host
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package server
+
+import "embed"
+
+// staticFiles contains the web UI
+//
+//go:embed static
+var staticFiles embed.FS
@@ -0,0 +1,123 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// elasticsearchSink indexes events with the _bulk API of Elasticsearch or OpenSearch
+type elasticsearchSink struct {
+	bulkURL   string
+	index     string
+	batchSize int
+	sender    *httpSender
+}
+
+// newElasticsearchSink creates a sink from the URL of the index, e.g. http://localhost:9200/ecs-logs.
+// The index may follow a path prefix, e.g. behind a reverse proxy: http://proxy/es/ecs-logs.
+func newElasticsearchSink(indexURL string, batchSize int, sender *httpSender) (*elasticsearchSink, error) {
+	u, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid elasticsearch URL: %v", err)
+	}
+	prefix, index := "", strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(index, "/"); i >= 0 {
+		prefix, index = index[:i], index[i+1:]
+	}
+	if index == "" {
+		return nil, fmt.Errorf("elasticsearch URL must end with the index name, e.g. http://localhost:9200/ecs-logs")
+	}
+	u.Path = strings.TrimSuffix("/"+prefix, "/") + "/_bulk"
+	u.RawPath = ""
+	return &elasticsearchSink{bulkURL: u.String(), index: index, batchSize: batchSize, sender: sender}, nil
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// Write indexes the events with one _bulk request per batch. Events are indexed with their @ptr as
+// the document ID, so that writing the same events again does not duplicate them.
+func (s *elasticsearchSink) Write(ctx context.Context, events [][]cwTypes.ResultField) error {
+	return writeBatches(events, s.batchSize, func(batch [][]cwTypes.ResultField) error {
+		var body bytes.Buffer
+		for _, event := range batch {
+			action, err := json.Marshal(map[string]any{"index": s.indexAction(event)})
+			if err != nil {
+				return err
+			}
+			doc, err := json.Marshal(elasticsearchDocument(event))
+			if err != nil {
+				return err
+			}
+			body.Write(action)
+			body.WriteByte('\n')
+			body.Write(doc)
+			body.WriteByte('\n')
+		}
+
+		respBody, err := s.sender.post(ctx, s.bulkURL, "application/x-ndjson", body.Bytes())
+		if err != nil {
+			return err
+		}
+		return bulkError(respBody)
+	})
+}
+
+// indexAction returns the metadata of the index action of an event
+func (s *elasticsearchSink) indexAction(event []cwTypes.ResultField) map[string]string {
+	action := map[string]string{"_index": s.index}
+	if ptr, ok := cloudwatchclient.FieldValue(event, "@ptr"); ok && ptr != "" {
+		action["_id"] = ptr
+	}
+	return action
+}
+
+// elasticsearchDocument returns the fields of an event with @timestamp in RFC3339,
+// which Elasticsearch maps as a date
+func elasticsearchDocument(event []cwTypes.ResultField) map[string]string {
+	doc := eventFields(event)
+	if value, ok := doc["@timestamp"]; ok {
+		if t, err := cloudwatchclient.ParseTimestamp(value); err == nil {
+			doc["@timestamp"] = t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return doc
+}
+
+// bulkError reports the documents rejected in a _bulk response
+func bulkError(respBody []byte) error {
+	var resp bulkResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse bulk response: %v", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+
+	failed := 0
+	var first string
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Status >= 300 {
+				failed++
+				if first == "" {
+					first = string(result.Error)
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%d of %d documents were rejected: %s", failed, len(resp.Items), first)
+}
@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// lokiSink pushes events to the Loki push API as a single stream with the configured labels
+type lokiSink struct {
+	url       string
+	labels    map[string]string
+	batchSize int
+	sender    *httpSender
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Write pushes the events with one request per batch, in chronological order within each batch
+func (s *lokiSink) Write(ctx context.Context, events [][]cwTypes.ResultField) error {
+	labels := map[string]string{"job": "ecs-log-viewer"}
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+
+	return writeBatches(events, s.batchSize, func(batch [][]cwTypes.ResultField) error {
+		stream := lokiStream{Stream: labels}
+		for _, event := range batch {
+			stream.Values = append(stream.Values, lokiEntry(event))
+		}
+		// Loki expects the entries of a stream in chronological order
+		sort.SliceStable(stream.Values, func(i, j int) bool {
+			a, _ := strconv.ParseInt(stream.Values[i][0], 10, 64)
+			b, _ := strconv.ParseInt(stream.Values[j][0], 10, 64)
+			return a < b
+		})
+
+		body, err := json.Marshal(lokiPush{Streams: []lokiStream{stream}})
+		if err != nil {
+			return err
+		}
+		_, err = s.sender.post(ctx, s.url, "application/json", body)
+		return err
+	})
+}
+
+// lokiEntry returns the nanosecond timestamp and line of an event. The line is the message when it is
+// the only field besides @timestamp, and a JSON object of the fields otherwise.
+func lokiEntry(event []cwTypes.ResultField) [2]string {
+	fields := eventFields(event)
+
+	ts := time.Now()
+	if value, ok := fields["@timestamp"]; ok {
+		if t, err := cloudwatchclient.ParseTimestamp(value); err == nil {
+			ts = t
+		}
+		delete(fields, "@timestamp")
+	}
+
+	line, ok := fields["@message"]
+	if !ok || len(fields) > 1 {
+		data, _ := json.Marshal(fields)
+		line = string(data)
+	}
+	return [2]string{strconv.FormatInt(ts.UnixNano(), 10), line}
+}
@@ -0,0 +1,160 @@
+// Package sink forwards CloudWatch log events to external systems such as Loki,
+// Elasticsearch/OpenSearch or a generic HTTP webhook.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Sink sends log events to an external system
+type Sink interface {
+	// Write sends the events, in batches when the sink has a batch size
+	Write(ctx context.Context, events [][]cwTypes.ResultField) error
+}
+
+// Options configures the sinks created with New
+type Options struct {
+	// BatchSize is the maximum number of events sent in one request
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried
+	MaxRetries int
+	// RetryWait is the wait before the first retry. It doubles with every retry.
+	RetryWait time.Duration
+	// Labels are attached to the events by sinks that support them (Loki stream labels)
+	Labels map[string]string
+	// Client sends the requests. Defaults to a client with a 30 second timeout.
+	Client *http.Client
+}
+
+const (
+	defaultBatchSize = 500
+	defaultRetryWait = time.Second
+)
+
+// New creates a sink from a spec in the form type=url. Supported types:
+//   - loki: Loki base URL, e.g. loki=http://localhost:3100
+//   - elasticsearch (or opensearch): URL of the index, e.g. elasticsearch=http://localhost:9200/ecs-logs
+//   - webhook: URL receiving a JSON array of events per batch
+func New(spec string, opt Options) (Sink, error) {
+	kind, url, ok := strings.Cut(spec, "=")
+	if !ok || url == "" {
+		return nil, fmt.Errorf("invalid sink %q: expected type=url", spec)
+	}
+
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = defaultBatchSize
+	}
+	if opt.MaxRetries < 0 {
+		opt.MaxRetries = 0
+	}
+	if opt.RetryWait <= 0 {
+		opt.RetryWait = defaultRetryWait
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	sender := &httpSender{client: opt.Client, maxRetries: opt.MaxRetries, retryWait: opt.RetryWait}
+
+	switch strings.ToLower(kind) {
+	case "loki":
+		return &lokiSink{url: strings.TrimRight(url, "/") + "/loki/api/v1/push", labels: opt.Labels, batchSize: opt.BatchSize, sender: sender}, nil
+	case "elasticsearch", "opensearch":
+		return newElasticsearchSink(url, opt.BatchSize, sender)
+	case "webhook":
+		return &webhookSink{url: url, batchSize: opt.BatchSize, sender: sender}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sink type: %s", kind)
+	}
+}
+
+// writeBatches calls send with consecutive batches of at most size events
+func writeBatches(events [][]cwTypes.ResultField, size int, send func(batch [][]cwTypes.ResultField) error) error {
+	for start := 0; start < len(events); start += size {
+		end := min(start+size, len(events))
+		if err := send(events[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventFields returns the fields of an event by name, skipping the @ptr field
+func eventFields(event []cwTypes.ResultField) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range event {
+		if field.Field == nil || *field.Field == "@ptr" {
+			continue
+		}
+		if field.Value != nil {
+			fields[*field.Field] = *field.Value
+		} else {
+			fields[*field.Field] = ""
+		}
+	}
+	return fields
+}
+
+// httpSender posts request bodies, retrying on network errors, 429 and 5xx responses
+type httpSender struct {
+	client     *http.Client
+	maxRetries int
+	retryWait  time.Duration
+}
+
+// post sends the body and returns the response body of a successful request
+func (s *httpSender) post(ctx context.Context, url, contentType string, body []byte) ([]byte, error) {
+	wait := s.retryWait
+	for attempt := 0; ; attempt++ {
+		respBody, retry, err := s.postOnce(ctx, url, contentType, body)
+		if err == nil {
+			return respBody, nil
+		}
+		if !retry || attempt >= s.maxRetries {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (s *httpSender) postOnce(ctx context.Context, url, contentType string, body []byte) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close response body: %v\n", err)
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, retry, fmt.Errorf("POST %s: %s: %s", url, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, false, nil
+}
@@ -0,0 +1,215 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func event(ts, message string) []cwTypes.ResultField {
+	return []cwTypes.ResultField{
+		{Field: stringPtr("@timestamp"), Value: stringPtr(ts)},
+		{Field: stringPtr("@message"), Value: stringPtr(message)},
+		{Field: stringPtr("@ptr"), Value: stringPtr("ptr")},
+	}
+}
+
+// recorder is a test server that records the request bodies and replies with the queued responses
+type recorder struct {
+	mu        sync.Mutex
+	paths     []string
+	bodies    []string
+	statuses  []int
+	responses []string
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.bodies = append(r.bodies, string(body))
+
+	status, response := http.StatusOK, "{}"
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if len(r.responses) > 0 {
+		response, r.responses = r.responses[0], r.responses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, response)
+}
+
+func newTestSink(t *testing.T, kind string, rec *recorder, opt Options) Sink {
+	t.Helper()
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	url := server.URL
+	if kind == "elasticsearch" {
+		url += "/ecs-logs"
+	}
+	opt.RetryWait = time.Millisecond
+	s, err := New(kind+"="+url, opt)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return s
+}
+
+func TestLokiSink(t *testing.T) {
+	rec := &recorder{}
+	s := newTestSink(t, "loki", rec, Options{Labels: map[string]string{"container": "app"}})
+
+	events := [][]cwTypes.ResultField{
+		event("2026-10-16 14:00:01.000", "second"),
+		event("2026-10-16 14:00:00.000", "first"),
+	}
+	if err := s.Write(context.Background(), events); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	if len(rec.paths) != 1 || rec.paths[0] != "/loki/api/v1/push" {
+		t.Fatalf("paths = %v", rec.paths)
+	}
+	var push lokiPush
+	if err := json.Unmarshal([]byte(rec.bodies[0]), &push); err != nil {
+		t.Fatalf("invalid push body: %v", err)
+	}
+	stream := push.Streams[0]
+	if stream.Stream["job"] != "ecs-log-viewer" || stream.Stream["container"] != "app" {
+		t.Errorf("labels = %v", stream.Stream)
+	}
+	want := [][2]string{
+		{"1792159200000000000", "first"},
+		{"1792159201000000000", "second"},
+	}
+	if len(stream.Values) != 2 || stream.Values[0] != want[0] || stream.Values[1] != want[1] {
+		t.Errorf("values = %v, want %v", stream.Values, want)
+	}
+}
+
+func TestElasticsearchSink(t *testing.T) {
+	rec := &recorder{responses: []string{`{"errors":false,"items":[]}`}}
+	s := newTestSink(t, "elasticsearch", rec, Options{})
+
+	if err := s.Write(context.Background(), [][]cwTypes.ResultField{event("2026-10-16 14:00:00.000", "hello")}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	if rec.paths[0] != "/_bulk" {
+		t.Errorf("path = %s, want /_bulk", rec.paths[0])
+	}
+	want := `{"index":{"_id":"ptr","_index":"ecs-logs"}}` + "\n" +
+		`{"@message":"hello","@timestamp":"2026-10-16T14:00:00Z"}` + "\n"
+	if rec.bodies[0] != want {
+		t.Errorf("body = %q, want %q", rec.bodies[0], want)
+	}
+}
+
+func TestElasticsearchSink_PathPrefix(t *testing.T) {
+	rec := &recorder{responses: []string{`{"errors":false,"items":[]}`}}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	s, err := New("elasticsearch="+server.URL+"/es/ecs-logs", Options{})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := s.Write(context.Background(), [][]cwTypes.ResultField{event("2026-10-16 14:00:00.000", "hello")}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	if rec.paths[0] != "/es/_bulk" {
+		t.Errorf("path = %s, want /es/_bulk", rec.paths[0])
+	}
+	if !strings.HasPrefix(rec.bodies[0], `{"index":{"_id":"ptr","_index":"ecs-logs"}}`) {
+		t.Errorf("body = %q", rec.bodies[0])
+	}
+}
+
+func TestElasticsearchSink_RejectedDocuments(t *testing.T) {
+	rec := &recorder{responses: []string{
+		`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`,
+	}}
+	s := newTestSink(t, "elasticsearch", rec, Options{})
+
+	err := s.Write(context.Background(), [][]cwTypes.ResultField{
+		event("2026-10-16 14:00:00.000", "a"),
+		event("2026-10-16 14:00:00.000", "b"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 documents") || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("Write() error = %v", err)
+	}
+}
+
+func TestWebhookSink_Batches(t *testing.T) {
+	rec := &recorder{}
+	s := newTestSink(t, "webhook", rec, Options{BatchSize: 2})
+
+	events := [][]cwTypes.ResultField{
+		event("2026-10-16 14:00:00.000", "a"),
+		event("2026-10-16 14:00:01.000", "b"),
+		event("2026-10-16 14:00:02.000", "c"),
+	}
+	if err := s.Write(context.Background(), events); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	if len(rec.bodies) != 2 {
+		t.Fatalf("got %d requests, want 2", len(rec.bodies))
+	}
+	var docs []map[string]string
+	if err := json.Unmarshal([]byte(rec.bodies[1]), &docs); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if len(docs) != 1 || docs[0]["@message"] != "c" {
+		t.Errorf("second batch = %v", docs)
+	}
+}
+
+func TestWebhookSink_Retry(t *testing.T) {
+	rec := &recorder{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+	s := newTestSink(t, "webhook", rec, Options{MaxRetries: 2})
+
+	if err := s.Write(context.Background(), [][]cwTypes.ResultField{event("2026-10-16 14:00:00.000", "a")}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if len(rec.bodies) != 3 {
+		t.Errorf("got %d requests, want 3", len(rec.bodies))
+	}
+}
+
+func TestWebhookSink_NoRetryOnClientError(t *testing.T) {
+	rec := &recorder{statuses: []int{http.StatusBadRequest}}
+	s := newTestSink(t, "webhook", rec, Options{MaxRetries: 2})
+
+	if err := s.Write(context.Background(), [][]cwTypes.ResultField{event("2026-10-16 14:00:00.000", "a")}); err == nil {
+		t.Error("Write() expected an error")
+	}
+	if len(rec.bodies) != 1 {
+		t.Errorf("got %d requests, want 1", len(rec.bodies))
+	}
+}
+
+func TestNew_InvalidSpec(t *testing.T) {
+	for _, spec := range []string{"loki", "kafka=localhost:9092", "elasticsearch=http://localhost:9200", "webhook="} {
+		if _, err := New(spec, Options{}); err == nil {
+			t.Errorf("New(%q) expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// webhookSink posts each batch of events as a JSON array of objects
+type webhookSink struct {
+	url       string
+	batchSize int
+	sender    *httpSender
+}
+
+// Write posts the events with one request per batch
+func (s *webhookSink) Write(ctx context.Context, events [][]cwTypes.ResultField) error {
+	return writeBatches(events, s.batchSize, func(batch [][]cwTypes.ResultField) error {
+		docs := make([]map[string]string, len(batch))
+		for i, event := range batch {
+			docs[i] = eventFields(event)
+		}
+		body, err := json.Marshal(docs)
+		if err != nil {
+			return err
+		}
+		_, err = s.sender.post(ctx, s.url, "application/json", body)
+		return err
+	})
+}
@@ -0,0 +1,383 @@
+// Package sqliteexport writes CloudWatch log events into a SQLite database for ad-hoc SQL queries.
+package sqliteexport
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultTable is the table the events are written to unless another one is given
+const DefaultTable = "logs"
+
+const (
+	// jsonColumnPrefix prefixes the columns of the keys of JSON messages
+	jsonColumnPrefix = "json_"
+	// ptrColumn holds @ptr, which identifies an event so that overlapping exports are not duplicated
+	ptrColumn = "ptr"
+	// maxColumns is the number of columns a table gets at most, well below SQLite's default limit
+	// of 2000. JSON keys beyond it get no column and are only available in the message column.
+	maxColumns = 1000
+	// mappingTable records the column of every source of each table, so that a source keeps its
+	// column across writes even when names collide differently in later events
+	mappingTable = "ecs_log_viewer_columns"
+)
+
+var nonIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// column is the column a value of an event is stored in. Values are identified by their source:
+// a fieldSource, derivedSource or jsonSource key.
+type column struct {
+	name string
+	// typ is the declared type. JSON values have none so that numbers keep their type.
+	typ string
+	// priority decides which source keeps the name when names collide, lowest first
+	priority int
+}
+
+// priorities of the kinds of sources
+const (
+	derivedPriority = iota
+	fieldPriority
+	jsonPriority
+)
+
+// fieldSource, derivedSource and jsonSource identify the values of fields, derived columns and JSON keys,
+// which cannot be confused with each other
+func fieldSource(name string) string   { return "field:" + name }
+func derivedSource(name string) string { return "derived:" + name }
+func jsonSource(key string) string     { return "json:" + key }
+
+// Write appends the events to the table in the SQLite database at path, creating both when they
+// do not exist. Each field becomes a column named without the leading @ (e.g. @message -> message).
+// In addition:
+//   - the top-level keys of JSON messages become json_<key> columns
+//   - task_id and container columns are derived from @logStream (prefix/container/task-id)
+//   - @ptr is stored in a unique ptr column, so events already in the table are skipped
+//
+// Column names are case-insensitive in SQLite, so of names differing only in case (or in characters
+// replaced by _), the first one in sort order keeps the name and the others get a suffix (msg_2).
+// The column of every source is recorded in mappingTable, so later writes use the same columns.
+// Columns missing from an existing table are added up to maxColumns, and the timestamp column is indexed.
+// It returns the number of inserted events.
+func Write(path, table string, events [][]cwTypes.ResultField) (int, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+
+	rows := make([]map[string]any, len(events))
+	sources := make(map[string]column)
+	for i, event := range events {
+		rows[i] = eventRow(event, sources)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Warning: failed to close database: %v\n", err)
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		// the transaction is done after a successful commit
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Warning: failed to roll back transaction: %v\n", err)
+		}
+	}()
+
+	columns, err := prepareTable(tx, table, sources)
+	if err != nil {
+		return 0, err
+	}
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col.name)
+		placeholders[i] = "?"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			log.Printf("Warning: failed to close statement: %v\n", err)
+		}
+	}()
+
+	inserted := 0
+	args := make([]any, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			args[i] = row[col.source]
+		}
+		result, err := stmt.Exec(args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert event: %v", err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			inserted += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// eventRow returns the values of an event by source, registering the columns of the sources
+func eventRow(event []cwTypes.ResultField, sources map[string]column) map[string]any {
+	row := make(map[string]any)
+	set := func(source string, col column, value any) {
+		row[source] = value
+		if _, ok := sources[source]; !ok {
+			sources[source] = col
+		}
+	}
+
+	for _, field := range event {
+		if field.Field == nil || field.Value == nil {
+			continue
+		}
+		name, value := *field.Field, *field.Value
+
+		switch name {
+		case "@ptr":
+			set(derivedSource(ptrColumn), column{name: ptrColumn, typ: "TEXT", priority: derivedPriority}, value)
+			continue
+		case "@logStream":
+			if container, taskID, ok := parseLogStream(value); ok {
+				set(derivedSource("container"), column{name: "container", typ: "TEXT", priority: derivedPriority}, container)
+				set(derivedSource("task_id"), column{name: "task_id", typ: "TEXT", priority: derivedPriority}, taskID)
+			}
+		case "@message":
+			var object map[string]any
+			if err := json.Unmarshal([]byte(value), &object); err == nil {
+				for key, v := range object {
+					set(jsonSource(key), column{name: columnName(jsonColumnPrefix + key), priority: jsonPriority}, jsonValue(v))
+				}
+			}
+		}
+		set(fieldSource(name), column{name: columnName(name), typ: "TEXT", priority: fieldPriority}, value)
+	}
+	return row
+}
+
+// insertColumn is a column values are inserted into
+type insertColumn struct {
+	source string
+	name   string
+	// added is set when the source had no recorded column before
+	added bool
+}
+
+// prepareTable creates the table or adds the missing columns, and returns the columns to insert
+func prepareTable(tx *sql.Tx, table string, sources map[string]column) ([]insertColumn, error) {
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s TEXT UNIQUE)",
+		quoteIdentifier(table), quoteIdentifier(ptrColumn))); err != nil {
+		return nil, fmt.Errorf("failed to create table: %v", err)
+	}
+
+	existing, err := tableColumns(tx, table)
+	if err != nil {
+		return nil, err
+	}
+	assigned, err := assignedColumns(tx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := assignColumns(sources, existing, assigned)
+	for _, col := range columns {
+		if col.added {
+			if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s ("table", source, name) VALUES (?, ?, ?)`,
+				quoteIdentifier(mappingTable)), table, col.source, col.name); err != nil {
+				return nil, fmt.Errorf("failed to record column %s: %v", col.name, err)
+			}
+		}
+		if existing[strings.ToLower(col.name)] {
+			continue
+		}
+		if _, err := tx.Exec(strings.TrimSpace(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+			quoteIdentifier(table), quoteIdentifier(col.name), sources[col.source].typ))); err != nil {
+			return nil, fmt.Errorf("failed to add column %s: %v", col.name, err)
+		}
+		existing[strings.ToLower(col.name)] = true
+	}
+
+	if existing["timestamp"] {
+		if _, err := tx.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (timestamp)",
+			quoteIdentifier("idx_"+table+"_timestamp"), quoteIdentifier(table))); err != nil {
+			return nil, fmt.Errorf("failed to create index: %v", err)
+		}
+	}
+	return columns, nil
+}
+
+// assignColumns returns the column of every source. Sources keep the column assigned to them before, and
+// new sources get distinct column names, ignoring case, in order of priority and name, with lower-case names
+// first. Colliding names get a suffix (msg_2). An existing column no source is assigned to, like one written
+// before columns were recorded, is taken by the source of the same name. JSON keys that would add columns
+// beyond maxColumns to the table with the existing (lower-cased) columns are left out.
+func assignColumns(sources map[string]column, existing map[string]bool, assigned map[string]string) []insertColumn {
+	keys := make([]string, 0, len(sources))
+	for source := range sources {
+		keys = append(keys, source)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := sources[keys[i]], sources[keys[j]]
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		if la, lb := strings.ToLower(a.name), strings.ToLower(b.name); la != lb {
+			return la < lb
+		}
+		if a.name != b.name {
+			return a.name > b.name
+		}
+		return keys[i] < keys[j]
+	})
+
+	used := make(map[string]bool)
+	for _, name := range assigned {
+		used[strings.ToLower(name)] = true
+	}
+
+	added := 0
+	var columns []insertColumn
+	for _, source := range keys {
+		if name, ok := assigned[source]; ok {
+			columns = append(columns, insertColumn{source: source, name: name})
+			continue
+		}
+
+		col := sources[source]
+		name := col.name
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", col.name, n)
+		}
+		if !existing[strings.ToLower(name)] {
+			if col.priority == jsonPriority && len(existing)+added >= maxColumns {
+				continue
+			}
+			added++
+		}
+		used[strings.ToLower(name)] = true
+		columns = append(columns, insertColumn{source: source, name: name, added: true})
+	}
+	return columns
+}
+
+// assignedColumns returns the recorded column names of the sources of the table, creating mappingTable when needed
+func assignedColumns(tx *sql.Tx, table string) (map[string]string, error) {
+	if _, err := tx.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s ("table" TEXT, source TEXT, name TEXT, PRIMARY KEY ("table", source))`,
+		quoteIdentifier(mappingTable))); err != nil {
+		return nil, fmt.Errorf("failed to create column mapping table: %v", err)
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`SELECT source, name FROM %s WHERE "table" = ?`, quoteIdentifier(mappingTable)), table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	assigned := make(map[string]string)
+	for rows.Next() {
+		var source, name string
+		if err := rows.Scan(&source, &name); err != nil {
+			return nil, err
+		}
+		assigned[source] = name
+	}
+	return assigned, rows.Err()
+}
+
+// tableColumns returns the lower-cased column names of the table
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", quoteIdentifier(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name, typ  string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			return nil, err
+		}
+		columns[strings.ToLower(name)] = true
+	}
+	return columns, rows.Err()
+}
+
+// parseLogStream returns the container name and task ID of an awslogs stream name (prefix/container/task-id)
+func parseLogStream(logStream string) (container, taskID string, ok bool) {
+	parts := strings.Split(logStream, "/")
+	if len(parts) < 3 {
+		return "", "", false
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], true
+}
+
+// jsonValue converts a decoded JSON value into a value SQLite stores with a matching type.
+// Objects and arrays are kept as JSON text for use with json_extract.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case nil, string, float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// columnName turns a field name into a column name, e.g. @logStream -> logStream
+func columnName(field string) string {
+	name := nonIdentifierChars.ReplaceAllString(strings.TrimPrefix(field, "@"), "_")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,221 @@
+package sqliteexport
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func event(ptr, ts, message string) []cwTypes.ResultField {
+	return []cwTypes.ResultField{
+		{Field: stringPtr("@timestamp"), Value: stringPtr(ts)},
+		{Field: stringPtr("@logStream"), Value: stringPtr("ecs/app/0123456789abcdef")},
+		{Field: stringPtr("@message"), Value: stringPtr(message)},
+		{Field: stringPtr("@ptr"), Value: stringPtr(ptr)},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	events := [][]cwTypes.ResultField{
+		event("a", "2026-10-16 14:00:00.000", `{"level":"error","status":500,"req":{"id":"r1"}}`),
+		event("b", "2026-10-16 14:00:01.000", "plain text"),
+	}
+
+	n, err := Write(path, "", events)
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Write() inserted %d events, want 2", n)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		container, taskID, level, reqID string
+		status                          int
+	)
+	err = db.QueryRow(`SELECT container, task_id, json_level, json_status, json_extract(json_req, '$.id')
+		FROM logs WHERE ptr = 'a'`).Scan(&container, &taskID, &level, &status, &reqID)
+	if err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	if container != "app" || taskID != "0123456789abcdef" {
+		t.Errorf("container, task_id = %q, %q", container, taskID)
+	}
+	if level != "error" || status != 500 || reqID != "r1" {
+		t.Errorf("json columns = %q, %d, %q", level, status, reqID)
+	}
+
+	var index string
+	if err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'index' AND name = 'idx_logs_timestamp'`).Scan(&index); err != nil {
+		t.Errorf("timestamp index not found: %v", err)
+	}
+}
+
+func TestWrite_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+
+	if _, err := Write(path, "logs", [][]cwTypes.ResultField{
+		event("a", "2026-10-16 14:00:00.000", "plain text"),
+	}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	// the second export overlaps with the first one and has a new JSON key
+	n, err := Write(path, "logs", [][]cwTypes.ResultField{
+		event("a", "2026-10-16 14:00:00.000", "plain text"),
+		event("b", "2026-10-16 14:00:01.000", `{"service":"api"}`),
+	})
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Write() inserted %d events, want 1", n)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM logs WHERE json_service = 'api'`).Scan(&count); err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+}
+
+func TestWrite_CaseInsensitiveColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+
+	if _, err := Write(path, "", [][]cwTypes.ResultField{
+		event("a", "2026-10-16 14:00:00.000", `{"Msg":"upper","msg":"lower","a.b":1,"a_b":2}`),
+	}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var lower, upper string
+	var dotted, underscored int
+	err = db.QueryRow(`SELECT json_msg, json_Msg_2, json_a_b, json_a_b_2 FROM logs`).Scan(&lower, &upper, &dotted, &underscored)
+	if err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	if lower != "lower" || upper != "upper" || dotted != 1 || underscored != 2 {
+		t.Errorf("columns = %q, %q, %d, %d", lower, upper, dotted, underscored)
+	}
+}
+
+func TestWrite_StableColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+
+	// only the upper-case key is seen first, so it gets the unnumbered column
+	if _, err := Write(path, "", [][]cwTypes.ResultField{
+		event("a", "2026-10-16 14:00:00.000", `{"Msg":"first"}`),
+	}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	// the lower-case key sorts first, but must not take over the column of the upper-case key
+	if _, err := Write(path, "", [][]cwTypes.ResultField{
+		event("b", "2026-10-16 14:00:01.000", `{"Msg":"second","msg":"lower"}`),
+	}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT json_Msg, coalesce(json_msg_2, '') FROM logs ORDER BY timestamp`)
+	if err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var upper, lower string
+		if err := rows.Scan(&upper, &lower); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, upper+"/"+lower)
+	}
+	if want := []string{"first/", "second/lower"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestWrite_MaxColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+
+	object := make(map[string]int)
+	for i := 0; i < maxColumns+100; i++ {
+		object[fmt.Sprintf("key%04d", i)] = i
+	}
+	message, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Write(path, "", [][]cwTypes.ResultField{event("a", "2026-10-16 14:00:00.000", string(message))}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM pragma_table_info('logs')`).Scan(&count); err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	if count != maxColumns {
+		t.Errorf("table has %d columns, want %d", count, maxColumns)
+	}
+
+	// keys without a column are still available in the message
+	var value int
+	if err := db.QueryRow(`SELECT json_extract(message, '$.key1099') FROM logs`).Scan(&value); err != nil || value != 1099 {
+		t.Errorf("json_extract() = %d, %v", value, err)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[string]string{
+		"@timestamp":  "timestamp",
+		"@logStream":  "logStream",
+		"json_req.id": "json_req_id",
+		"@":           "_",
+	}
+	for field, want := range tests {
+		if got := columnName(field); got != want {
+			t.Errorf("columnName(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
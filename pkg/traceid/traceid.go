@@ -0,0 +1,66 @@
+// Package traceid turns request and trace IDs into the strings they appear as in log messages.
+package traceid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// traceparent is a W3C Trace Context header: version-traceid-parentid-flags
+	traceparent = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+	// xrayID is an X-Ray trace ID: 1-<epoch seconds in 8 hex digits>-<24 hex digits>
+	xrayID = regexp.MustCompile(`^1-([0-9a-f]{8})-([0-9a-f]{24})$`)
+	// w3cID is a W3C trace ID of 32 hex digits
+	w3cID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+)
+
+// Terms returns the strings to search log messages for to find a request or trace ID.
+// X-Ray trace headers ("Root=1-...;Parent=...") and W3C traceparent headers are reduced to
+// their trace ID. Trace IDs are searched in both the X-Ray and the W3C format, since services
+// instrumented with OpenTelemetry log X-Ray trace IDs as 32 hex digits. Any other ID, such as
+// a request ID, is searched as is.
+func Terms(id string) ([]string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("empty trace ID")
+	}
+
+	if root, ok := xrayRoot(id); ok {
+		id = root
+	}
+	lower := strings.ToLower(id)
+	if m := traceparent.FindStringSubmatch(lower); m != nil {
+		lower = m[1]
+	}
+
+	if m := xrayID.FindStringSubmatch(lower); m != nil {
+		return []string{lower, m[1] + m[2]}, nil
+	}
+	if w3cID.MatchString(lower) {
+		return []string{lower, "1-" + lower[:8] + "-" + lower[8:]}, nil
+	}
+	return []string{id}, nil
+}
+
+// xrayRoot returns the Root field of an X-Ray trace header such as
+// "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+func xrayRoot(header string) (string, bool) {
+	for _, part := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(key, "Root") {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// InsightsFilter returns a Logs Insights command keeping messages that contain any of the terms
+func InsightsFilter(terms []string) string {
+	conditions := make([]string, len(terms))
+	for i, term := range terms {
+		conditions[i] = "@message like '" + strings.ReplaceAll(term, "'", "\\'") + "'"
+	}
+	return "filter " + strings.Join(conditions, " or ")
+}
@@ -0,0 +1,54 @@
+package traceid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    []string
+		wantErr bool
+	}{
+		{
+			id:   "1-5759e988-bd862e3fe1be46a994272793",
+			want: []string{"1-5759e988-bd862e3fe1be46a994272793", "5759e988bd862e3fe1be46a994272793"},
+		},
+		{
+			id:   "Root=1-5759E988-BD862E3FE1BE46A994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+			want: []string{"1-5759e988-bd862e3fe1be46a994272793", "5759e988bd862e3fe1be46a994272793"},
+		},
+		{
+			id:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want: []string{"4bf92f3577b34da6a3ce929d0e0e4736", "1-4bf92f35-77b34da6a3ce929d0e0e4736"},
+		},
+		{
+			id:   "4bf92f3577b34da6a3ce929d0e0e4736",
+			want: []string{"4bf92f3577b34da6a3ce929d0e0e4736", "1-4bf92f35-77b34da6a3ce929d0e0e4736"},
+		},
+		{
+			id:   " req-7F3A9c ",
+			want: []string{"req-7F3A9c"},
+		},
+		{id: "  ", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Terms(tt.id)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Terms(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Terms(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestInsightsFilter(t *testing.T) {
+	got := InsightsFilter([]string{"abc", "it's"})
+	want := `filter @message like 'abc' or @message like 'it\'s'`
+	if got != want {
+		t.Errorf("InsightsFilter() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// tabulate converts events to rows of values for the fields in the order they first appear.
+// The @ptr field is skipped, as it is in the other output formats.
+func tabulate(events [][]cwTypes.ResultField) ([]string, [][]string) {
+	var fields []string
+	index := make(map[string]int)
+	for _, event := range events {
+		for _, field := range event {
+			name := aws.ToString(field.Field)
+			if _, ok := index[name]; ok || name == "@ptr" {
+				continue
+			}
+			index[name] = len(fields)
+			fields = append(fields, name)
+		}
+	}
+
+	rows := make([][]string, len(events))
+	for i, event := range events {
+		rows[i] = make([]string, len(fields))
+		for _, field := range event {
+			if j, ok := index[aws.ToString(field.Field)]; ok {
+				rows[i][j] = aws.ToString(field.Value)
+			}
+		}
+	}
+	return fields, rows
+}
+
+// matchRows returns the indexes of the rows containing term in any column, ignoring case
+func matchRows(rows [][]string, term string) []int {
+	term = strings.ToLower(term)
+	var matched []int
+	for i, row := range rows {
+		for _, value := range row {
+			if strings.Contains(strings.ToLower(value), term) {
+				matched = append(matched, i)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// hiddenColumns lists the hidden fields with their column numbers, e.g. "2:@logStream 3:level"
+func hiddenColumns(fields []string, hidden map[string]bool) string {
+	var columns []string
+	for i, field := range fields {
+		if hidden[field] {
+			columns = append(columns, strconv.Itoa(i+1)+":"+field)
+		}
+	}
+	return strings.Join(columns, " ")
+}
+
+// formatDetail formats all fields of an event, pretty-printing JSON values
+func formatDetail(fields []string, row []string) string {
+	var sb strings.Builder
+	for i, field := range fields {
+		sb.WriteString(field)
+		sb.WriteString(":\n")
+		sb.WriteString(prettyJSON(row[i]))
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// prettyJSON indents value if it is a JSON object or array, otherwise it returns value as is
+func prettyJSON(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return value
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(trimmed), "", "  "); err != nil {
+		return value
+	}
+	return buf.String()
+}
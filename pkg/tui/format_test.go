@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"testing"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ptr is a helper to get pointer to a string.
+func ptr(s string) *string {
+	return &s
+}
+
+func TestTabulate(t *testing.T) {
+	events := [][]cwTypes.ResultField{
+		{{Field: ptr("@timestamp"), Value: ptr("t1")}, {Field: ptr("@ptr"), Value: ptr("p")}},
+		{{Field: ptr("@message"), Value: ptr("m2")}, {Field: ptr("@timestamp"), Value: ptr("t2")}},
+	}
+
+	fields, rows := tabulate(events)
+
+	if len(fields) != 2 || fields[0] != "@timestamp" || fields[1] != "@message" {
+		t.Fatalf("Unexpected fields %v", fields)
+	}
+	if rows[0][0] != "t1" || rows[0][1] != "" || rows[1][0] != "t2" || rows[1][1] != "m2" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+}
+
+func TestMatchRows(t *testing.T) {
+	rows := [][]string{{"INFO started"}, {"ERROR failed"}, {"error again"}}
+
+	got := matchRows(rows, "Error")
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("matchRows() = %v, want [1 2]", got)
+	}
+}
+
+func TestHiddenColumns(t *testing.T) {
+	fields := []string{"@timestamp", "@logStream", "level", "@message"}
+
+	got := hiddenColumns(fields, map[string]bool{"level": true, "@logStream": true, "other": true})
+	if want := "2:@logStream 3:level"; got != want {
+		t.Errorf("hiddenColumns() = %q, want %q", got, want)
+	}
+	if got := hiddenColumns(fields, map[string]bool{}); got != "" {
+		t.Errorf("hiddenColumns() = %q, want empty", got)
+	}
+}
+
+func TestFormatDetail(t *testing.T) {
+	got := formatDetail([]string{"@timestamp", "@message"}, []string{"2025-02-16 10:00:00.000", `{"level":"info","msg":"ok"}`})
+	want := "@timestamp:\n2025-02-16 10:00:00.000\n\n@message:\n{\n  \"level\": \"info\",\n  \"msg\": \"ok\"\n}"
+	if got != want {
+		t.Errorf("formatDetail() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// Option configures the viewer
+type Option struct {
+	// Filter and Duration are the parameters used for the events passed to Run
+	Filter   string
+	Duration time.Duration
+	// Query runs the query again with a changed filter or time range
+	Query func(filter string, duration time.Duration) ([][]cwTypes.ResultField, error)
+}
+
+// inputMode is what the input line at the bottom is used for
+type inputMode int
+
+const (
+	inputSearch inputMode = iota
+	inputFilter
+	inputDuration
+)
+
+const helpText = "[::b]/[::-] search  [::b]f[::-] filter  [::b]d[::-] range  [::b]r[::-] re-run  [::b]1-9[::-] toggle column  [::b]q[::-] quit"
+
+// viewer is a full-screen browser for log events
+type viewer struct {
+	app    *tview.Application
+	table  *tview.Table
+	detail *tview.TextView
+	status *tview.TextView
+	input  *tview.InputField
+	layout *tview.Flex
+
+	option Option
+	mode   inputMode
+
+	fields  []string
+	rows    [][]string
+	hidden  map[string]bool
+	search  string
+	visible []int
+
+	// generation is incremented by every rerun, so that the results of an earlier one are ignored
+	generation int
+}
+
+// Run shows the events in a full-screen viewer until the user quits
+func Run(events [][]cwTypes.ResultField, option Option) error {
+	v := &viewer{
+		app:    tview.NewApplication(),
+		table:  tview.NewTable(),
+		detail: tview.NewTextView(),
+		status: tview.NewTextView(),
+		input:  tview.NewInputField(),
+		option: option,
+		hidden: make(map[string]bool),
+	}
+
+	v.table.SetFixed(1, 0).SetSelectable(true, false).SetBorder(true)
+	v.table.SetSelectionChangedFunc(func(row, column int) { v.showDetail(row) })
+	v.table.SetInputCapture(v.handleKey)
+
+	v.detail.SetDynamicColors(false).SetWrap(true).SetBorder(true).SetTitle(" Detail ")
+	v.status.SetDynamicColors(true)
+
+	v.input.SetChangedFunc(v.inputChanged)
+	v.input.SetDoneFunc(v.inputDone)
+
+	v.layout = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.status, 1, 0, false).
+		AddItem(v.table, 0, 3, true).
+		AddItem(v.detail, 0, 2, false).
+		AddItem(v.input, 1, 0, false)
+
+	v.setEvents(events)
+	return v.app.SetRoot(v.layout, true).EnableMouse(true).Run()
+}
+
+// setEvents replaces the events shown in the viewer
+func (v *viewer) setEvents(events [][]cwTypes.ResultField) {
+	v.fields, v.rows = tabulate(events)
+	v.render()
+}
+
+// render redraws the table with the visible columns and the rows matching the search
+func (v *viewer) render() {
+	v.visible = matchRows(v.rows, v.search)
+	v.table.Clear()
+
+	column := 0
+	for i, field := range v.fields {
+		if v.hidden[field] {
+			continue
+		}
+		v.table.SetCell(0, column, tview.NewTableCell(fmt.Sprintf("%d:%s", i+1, tview.Escape(field))).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold))
+		for r, rowIndex := range v.visible {
+			cell := tview.NewTableCell(tview.Escape(cloudwatchclient.SingleLine(v.rows[rowIndex][i])))
+			if i == len(v.fields)-1 {
+				cell.SetExpansion(1)
+			} else {
+				cell.SetMaxWidth(40)
+			}
+			v.table.SetCell(r+1, column, cell)
+		}
+		column++
+	}
+
+	if len(v.visible) > 0 {
+		v.table.Select(1, 0)
+	}
+	v.showDetail(1)
+	v.updateStatus("")
+}
+
+// showDetail shows all fields of the event at the table row
+func (v *viewer) showDetail(row int) {
+	if row < 1 || row > len(v.visible) {
+		v.detail.SetText("")
+		return
+	}
+	v.detail.SetText(formatDetail(v.fields, v.rows[v.visible[row-1]]))
+	v.detail.ScrollToBeginning()
+}
+
+func (v *viewer) updateStatus(message string) {
+	status := fmt.Sprintf(" [::b]%d/%d events[::-]  range %s", len(v.visible), len(v.rows), v.option.Duration)
+	if v.option.Filter != "" {
+		status += "  filter " + tview.Escape(fmt.Sprintf("%q", v.option.Filter))
+	}
+	if v.search != "" {
+		status += "  search " + tview.Escape(fmt.Sprintf("%q", v.search))
+	}
+	// hidden columns are not in the header, so their numbers are shown here to toggle them back
+	if hidden := hiddenColumns(v.fields, v.hidden); hidden != "" {
+		status += "  hidden " + tview.Escape(hidden)
+	}
+	if message != "" {
+		status += "  [yellow]" + tview.Escape(message) + "[-]"
+	} else {
+		status += "  " + helpText
+	}
+	v.status.SetText(status)
+}
+
+// handleKey handles the key bindings of the table
+func (v *viewer) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Rune() {
+	case 'q':
+		v.app.Stop()
+		return nil
+	case '/':
+		v.startInput(inputSearch, "Search: ", v.search)
+		return nil
+	case 'f':
+		v.startInput(inputFilter, "Filter (re-runs query): ", v.option.Filter)
+		return nil
+	case 'd':
+		v.startInput(inputDuration, "Range (re-runs query, e.g. 30m, 6h): ", v.option.Duration.String())
+		return nil
+	case 'r':
+		v.rerun()
+		return nil
+	}
+
+	if r := event.Rune(); r >= '1' && r <= '9' {
+		if i := int(r - '1'); i < len(v.fields) {
+			v.hidden[v.fields[i]] = !v.hidden[v.fields[i]]
+			v.render()
+		}
+		return nil
+	}
+	return event
+}
+
+func (v *viewer) startInput(mode inputMode, label, value string) {
+	v.mode = mode
+	v.input.SetLabel(label).SetText(value)
+	v.app.SetFocus(v.input)
+}
+
+// inputChanged applies the search incrementally while typing
+func (v *viewer) inputChanged(text string) {
+	if v.mode == inputSearch && v.app.GetFocus() == v.input {
+		v.search = text
+		v.render()
+	}
+}
+
+func (v *viewer) inputDone(key tcell.Key) {
+	text := v.input.GetText()
+	mode := v.mode
+	// move the focus first so that clearing the input does not clear the search
+	v.app.SetFocus(v.table)
+	v.input.SetLabel("").SetText("")
+
+	if key == tcell.KeyEscape {
+		if mode == inputSearch {
+			v.search = ""
+			v.render()
+		}
+		return
+	}
+
+	switch mode {
+	case inputFilter:
+		v.option.Filter = text
+		v.rerun()
+	case inputDuration:
+		duration, err := time.ParseDuration(text)
+		if err != nil || duration <= 0 {
+			v.updateStatus(fmt.Sprintf("invalid range: %s", text))
+			return
+		}
+		v.option.Duration = duration
+		v.rerun()
+	}
+}
+
+// rerun runs the query again in the background with the current filter and range
+func (v *viewer) rerun() {
+	if v.option.Query == nil {
+		return
+	}
+	v.updateStatus("querying...")
+
+	v.generation++
+	generation := v.generation
+	option := v.option
+	go func() {
+		events, err := option.Query(option.Filter, option.Duration)
+		v.app.QueueUpdateDraw(func() {
+			if generation != v.generation {
+				return
+			}
+			if err != nil {
+				v.updateStatus(fmt.Sprintf("query failed: %v", err))
+				return
+			}
+			v.setEvents(events)
+		})
+	}()
+}
@@ -0,0 +1,132 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// DesktopNotifier shows alerts as desktop notifications with notify-send on Linux
+// and osascript on macOS
+type DesktopNotifier struct{}
+
+// Notify shows the alert message as a desktop notification
+func (DesktopNotifier) Notify(ctx context.Context, alert Alert) error {
+	name, args, err := desktopCommand(runtime.GOOS, "ecs-log-viewer", alert.Message())
+	if err != nil {
+		return err
+	}
+	return exec.CommandContext(ctx, name, args...).Run()
+}
+
+// desktopCommand returns the command showing a desktop notification on the OS
+func desktopCommand(goos, title, message string) (string, []string, error) {
+	switch goos {
+	case "linux":
+		return "notify-send", []string{title, message}, nil
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+		return "osascript", []string{"-e", script}, nil
+	default:
+		return "", nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+	}
+}
+
+// CommandNotifier runs a shell command for each alert. The alert is passed in the environment
+// variables ECS_LOG_VIEWER_RULE, ECS_LOG_VIEWER_COUNT, ECS_LOG_VIEWER_MESSAGE and ECS_LOG_VIEWER_SAMPLE.
+type CommandNotifier struct {
+	Command string
+}
+
+// Notify runs the command with the alert in its environment. Its output goes to stderr.
+func (n CommandNotifier) Notify(ctx context.Context, alert Alert) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", n.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", n.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"ECS_LOG_VIEWER_RULE="+alert.Rule.Expr,
+		"ECS_LOG_VIEWER_COUNT="+strconv.Itoa(alert.Count),
+		"ECS_LOG_VIEWER_MESSAGE="+alert.Message(),
+		"ECS_LOG_VIEWER_SAMPLE="+alert.Sample,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("alert command failed: %v", err)
+	}
+	return nil
+}
+
+// WebhookNotifier posts each alert as a JSON object
+type WebhookNotifier struct {
+	URL string
+	// Client sends the requests. Defaults to a client with a 10 second timeout.
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	Rule    string    `json:"rule"`
+	Count   int       `json:"count"`
+	Window  string    `json:"window,omitempty"`
+	Message string    `json:"message"`
+	Sample  string    `json:"sample"`
+	Time    time.Time `json:"time"`
+}
+
+// Notify posts the alert to the URL
+func (n WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload := webhookPayload{
+		Rule:    alert.Rule.Expr,
+		Count:   alert.Count,
+		Message: alert.Message(),
+		Sample:  alert.Sample,
+		Time:    alert.Time,
+	}
+	if alert.Rule.Window > 0 {
+		payload.Window = alert.Rule.Window.String()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close response body: %v\n", err)
+		}
+	}()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
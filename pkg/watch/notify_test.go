@@ -0,0 +1,86 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAlert(t *testing.T) Alert {
+	t.Helper()
+	return Alert{
+		Rule:   mustParseRule(t, "/ERROR/ > 20 in 5m"),
+		Count:  23,
+		Sample: "ERROR: failed",
+		Time:   time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := (WebhookNotifier{URL: server.URL}).Notify(context.Background(), testAlert(t)); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	if payload.Rule != "/ERROR/ > 20 in 5m" || payload.Count != 23 || payload.Window != "5m0s" || payload.Sample != "ERROR: failed" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := (WebhookNotifier{URL: server.URL}).Notify(context.Background(), testAlert(t)); err == nil {
+		t.Error("Notify() expected an error")
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "alert.txt")
+	n := CommandNotifier{Command: `echo "$ECS_LOG_VIEWER_COUNT $ECS_LOG_VIEWER_SAMPLE" > ` + out}
+
+	if err := n.Notify(context.Background(), testAlert(t)); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "23 ERROR: failed" {
+		t.Errorf("command output = %q", got)
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	name, args, err := desktopCommand("darwin", "ecs-log-viewer", `say "hi"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `display notification "say \"hi\"" with title "ecs-log-viewer"`
+	if name != "osascript" || len(args) != 2 || args[1] != want {
+		t.Errorf("desktopCommand() = %s %q", name, args)
+	}
+
+	if _, _, err := desktopCommand("plan9", "title", "message"); err == nil {
+		t.Error("expected an error for an unsupported OS")
+	}
+}
@@ -0,0 +1,70 @@
+// Package watch evaluates alert rules against periodically fetched log events and
+// notifies when they trigger.
+package watch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule triggers when log messages match a pattern. A rule with a window triggers when more
+// than Threshold messages within the window match; a rule without one triggers on any new match.
+type Rule struct {
+	// Expr is the rule as written, e.g. "/ERROR/ > 20 in 5m"
+	Expr    string
+	Pattern *regexp.Regexp
+	// Threshold is the number of matches the window may contain without triggering
+	Threshold int
+	Window    time.Duration
+}
+
+// ruleSyntax matches "/PATTERN/[i]" optionally followed by "> N in DURATION" or ">= N in DURATION"
+var ruleSyntax = regexp.MustCompile(`^/(.+)/(i?)(?:\s*(>=?)\s*(\d+)\s+in\s+(\S+))?$`)
+
+// ParseRule parses a rule such as "/ERROR/ > 20 in 5m", "/timeout/i >= 3 in 1m" or "/OutOfMemory/".
+func ParseRule(expr string) (Rule, error) {
+	expr = strings.TrimSpace(expr)
+	m := ruleSyntax.FindStringSubmatch(expr)
+	if m == nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: expected /PATTERN/ or /PATTERN/ > N in DURATION", expr)
+	}
+
+	pattern := m[1]
+	if m[2] == "i" {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid pattern in rule %q: %v", expr, err)
+	}
+	rule := Rule{Expr: expr, Pattern: re}
+
+	if m[3] != "" {
+		n, err := strconv.Atoi(m[4])
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid count in rule %q: %v", expr, err)
+		}
+		window, err := time.ParseDuration(m[5])
+		if err != nil || window <= 0 {
+			return Rule{}, fmt.Errorf("invalid window in rule %q: %s", expr, m[5])
+		}
+		rule.Threshold, rule.Window = n, window
+		if m[3] == ">=" {
+			rule.Threshold = n - 1
+		}
+	}
+	return rule, nil
+}
+
+// InsightsFilter returns a Logs Insights filter command selecting the messages that match
+// any of the rules, so that only candidate events are fetched
+func InsightsFilter(rules []Rule) string {
+	conditions := make([]string, len(rules))
+	for i, rule := range rules {
+		conditions[i] = "@message like /" + strings.ReplaceAll(rule.Pattern.String(), "/", `\/`) + "/"
+	}
+	return "filter " + strings.Join(conditions, " or ")
+}
@@ -0,0 +1,51 @@
+package watch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		expr      string
+		threshold int
+		window    time.Duration
+		match     string
+	}{
+		{"/ERROR/ > 20 in 5m", 20, 5 * time.Minute, "ERROR: failed"},
+		{"/timeout/i >= 3 in 1m", 2, time.Minute, "Request TIMEOUT"},
+		{"/OutOfMemory/", 0, 0, "java.lang.OutOfMemoryError"},
+		{"  /a\\/b/  ", 0, 0, "a/b"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.expr)
+		if err != nil {
+			t.Errorf("ParseRule(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if rule.Threshold != tt.threshold || rule.Window != tt.window {
+			t.Errorf("ParseRule(%q) = threshold %d, window %s", tt.expr, rule.Threshold, rule.Window)
+		}
+		if !rule.Pattern.MatchString(tt.match) {
+			t.Errorf("ParseRule(%q) does not match %q", tt.expr, tt.match)
+		}
+	}
+}
+
+func TestParseRule_Invalid(t *testing.T) {
+	for _, expr := range []string{"ERROR", "/ERROR/ > 20", "/ERROR/ > x in 5m", "/ERROR/ > 20 in forever", "/(/"} {
+		if _, err := ParseRule(expr); err == nil {
+			t.Errorf("ParseRule(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestInsightsFilter(t *testing.T) {
+	a, _ := ParseRule("/ERROR/ > 20 in 5m")
+	b, _ := ParseRule("/oom/i")
+
+	want := `filter @message like /ERROR/ or @message like /(?i)oom/`
+	if got := InsightsFilter([]Rule{a, b}); got != want {
+		t.Errorf("InsightsFilter() = %q, want %q", got, want)
+	}
+}
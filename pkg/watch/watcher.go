@@ -0,0 +1,143 @@
+package watch
+
+import (
+	"fmt"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+)
+
+// IngestionSlack is how much longer than the interval each poll looks back, so that events
+// ingested after a poll with an earlier timestamp are still found. Events fetched by several
+// polls are identified by their @ptr.
+const IngestionSlack = 2 * time.Minute
+
+// Alert is a triggered rule
+type Alert struct {
+	Rule Rule
+	// Count is the number of matches in the window, or of new matches for rules without a window
+	Count int
+	// Sample is the latest matching message
+	Sample string
+	Time   time.Time
+}
+
+// Message describes the alert in one line
+func (a Alert) Message() string {
+	if a.Rule.Window > 0 {
+		return fmt.Sprintf("%s: %d matches in the last %s", a.Rule.Expr, a.Count, a.Rule.Window)
+	}
+	return fmt.Sprintf("%s: %d new matches", a.Rule.Expr, a.Count)
+}
+
+// Watcher evaluates rules against the events of successive polls. A rule with a window
+// alerts once when its count exceeds the threshold and again only after it has recovered.
+// A rule without a window alerts on matches it has not seen in earlier polls.
+type Watcher struct {
+	rules    []Rule
+	interval time.Duration
+	firing   []bool
+	// seen holds the keys of events already alerted on with their timestamps
+	seen map[string]time.Time
+}
+
+// NewWatcher creates a watcher for the rules polled every interval
+func NewWatcher(rules []Rule, interval time.Duration) *Watcher {
+	return &Watcher{
+		rules:    rules,
+		interval: interval,
+		firing:   make([]bool, len(rules)),
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// Lookback returns how far back each poll needs to fetch: the longest window, or the interval
+// with the IngestionSlack
+func (w *Watcher) Lookback() time.Duration {
+	lookback := w.interval + IngestionSlack
+	for _, rule := range w.rules {
+		lookback = max(lookback, rule.Window)
+	}
+	return lookback
+}
+
+// Evaluate returns the alerts triggered by the events fetched up to now.
+// The events need @timestamp and @message fields; @ptr identifies them across polls.
+func (w *Watcher) Evaluate(events [][]cwTypes.ResultField, now time.Time) []Alert {
+	var alerts []Alert
+	for i, rule := range w.rules {
+		count := 0
+		var sample string
+		var latest time.Time
+		for _, event := range events {
+			message, _ := cloudwatchclient.FieldValue(event, "@message")
+			if !rule.Pattern.MatchString(message) {
+				continue
+			}
+			value, _ := cloudwatchclient.FieldValue(event, "@timestamp")
+			ts, err := cloudwatchclient.ParseTimestamp(value)
+			if err != nil {
+				continue
+			}
+
+			if rule.Window > 0 {
+				if ts.Before(now.Add(-rule.Window)) {
+					continue
+				}
+			} else {
+				key := eventKey(rule, event)
+				if _, ok := w.seen[key]; ok {
+					continue
+				}
+				w.seen[key] = ts
+			}
+
+			count++
+			if !ts.Before(latest) {
+				latest, sample = ts, message
+			}
+		}
+
+		alert := Alert{Rule: rule, Count: count, Sample: sample, Time: now}
+		if rule.Window > 0 {
+			firing := count > rule.Threshold
+			if firing && !w.firing[i] {
+				alerts = append(alerts, alert)
+			}
+			w.firing[i] = firing
+		} else if count > 0 {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	w.forget(now.Add(-2 * w.Lookback()))
+	return alerts
+}
+
+// RedactAlerts replaces sensitive values of the samples in place with redact, e.g. the Redact
+// method of a redact.Redactor. The rules are evaluated on the original messages.
+func RedactAlerts(alerts []Alert, redact func(string) string) {
+	for i := range alerts {
+		alerts[i].Sample = redact(alerts[i].Sample)
+	}
+}
+
+// forget drops the seen events older than before, which later polls no longer fetch
+func (w *Watcher) forget(before time.Time) {
+	for key, ts := range w.seen {
+		if ts.Before(before) {
+			delete(w.seen, key)
+		}
+	}
+}
+
+// eventKey identifies an event for a rule, by @ptr when available
+func eventKey(rule Rule, event []cwTypes.ResultField) string {
+	if ptr, ok := cloudwatchclient.FieldValue(event, "@ptr"); ok {
+		return rule.Expr + "\x00" + ptr
+	}
+	ts, _ := cloudwatchclient.FieldValue(event, "@timestamp")
+	message, _ := cloudwatchclient.FieldValue(event, "@message")
+	return rule.Expr + "\x00" + ts + "\x00" + message
+}
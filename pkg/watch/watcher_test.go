@@ -0,0 +1,134 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/cloudwatchclient"
+	"github.com/bonyuta0204/ecs-log-viewer/pkg/redact"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func event(ptr string, ts time.Time, message string) []cwTypes.ResultField {
+	return []cwTypes.ResultField{
+		{Field: stringPtr("@timestamp"), Value: stringPtr(ts.UTC().Format(cloudwatchclient.TimestampLayout))},
+		{Field: stringPtr("@message"), Value: stringPtr(message)},
+		{Field: stringPtr("@ptr"), Value: stringPtr(ptr)},
+	}
+}
+
+func mustParseRule(t *testing.T, expr string) Rule {
+	t.Helper()
+	rule, err := ParseRule(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rule
+}
+
+func TestWatcher_Threshold(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	w := NewWatcher([]Rule{mustParseRule(t, "/ERROR/ > 1 in 5m")}, time.Minute)
+
+	events := [][]cwTypes.ResultField{
+		event("a", now.Add(-10*time.Minute), "ERROR old"),
+		event("b", now.Add(-time.Minute), "ERROR one"),
+		event("c", now.Add(-30*time.Second), "INFO ok"),
+	}
+	if alerts := w.Evaluate(events, now); len(alerts) != 0 {
+		t.Fatalf("expected no alerts below the threshold, got %v", alerts)
+	}
+
+	events = append(events, event("d", now.Add(-10*time.Second), "ERROR two"))
+	alerts := w.Evaluate(events, now)
+	if len(alerts) != 1 || alerts[0].Count != 2 || alerts[0].Sample != "ERROR two" {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	// still firing, so no repeated alert
+	if alerts := w.Evaluate(events, now.Add(time.Minute)); len(alerts) != 0 {
+		t.Errorf("expected no repeated alert, got %v", alerts)
+	}
+
+	// recovered after the window, then firing again alerts again
+	if alerts := w.Evaluate(nil, now.Add(10*time.Minute)); len(alerts) != 0 {
+		t.Errorf("expected no alerts after recovery, got %v", alerts)
+	}
+	later := now.Add(11 * time.Minute)
+	events = [][]cwTypes.ResultField{
+		event("e", later.Add(-time.Second), "ERROR three"),
+		event("f", later.Add(-time.Second), "ERROR four"),
+	}
+	if alerts := w.Evaluate(events, later); len(alerts) != 1 {
+		t.Errorf("expected an alert after firing again, got %v", alerts)
+	}
+}
+
+func TestWatcher_AnyMatch(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	w := NewWatcher([]Rule{mustParseRule(t, "/OutOfMemory/")}, time.Minute)
+
+	events := [][]cwTypes.ResultField{
+		event("a", now.Add(-30*time.Second), "java.lang.OutOfMemoryError"),
+	}
+	if alerts := w.Evaluate(events, now); len(alerts) != 1 || alerts[0].Count != 1 {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	// the next poll overlaps with the previous one; only the new match alerts
+	events = append(events, event("b", now.Add(10*time.Second), "OutOfMemory again"))
+	alerts := w.Evaluate(events, now.Add(30*time.Second))
+	if len(alerts) != 1 || alerts[0].Count != 1 || alerts[0].Sample != "OutOfMemory again" {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+}
+
+func TestWatcher_Lookback(t *testing.T) {
+	w := NewWatcher([]Rule{mustParseRule(t, "/a/"), mustParseRule(t, "/b/ > 1 in 5m")}, time.Minute)
+	if got := w.Lookback(); got != 5*time.Minute {
+		t.Errorf("Lookback() = %s, want 5m", got)
+	}
+
+	w = NewWatcher([]Rule{mustParseRule(t, "/a/")}, time.Minute)
+	if got, want := w.Lookback(), time.Minute+IngestionSlack; got != want {
+		t.Errorf("Lookback() = %s, want %s", got, want)
+	}
+}
+
+func TestWatcher_LateEvent(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	w := NewWatcher([]Rule{mustParseRule(t, "/panic/")}, time.Minute)
+
+	events := [][]cwTypes.ResultField{event("a", now.Add(-10*time.Second), "panic: first")}
+	if alerts := w.Evaluate(events, now); len(alerts) != 1 {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	// b was logged before the first poll but ingested after it; the slack of the next poll
+	// fetches it together with a, which already alerted
+	next := now.Add(time.Minute)
+	events = append(events, event("b", now.Add(-20*time.Second), "panic: late"))
+	alerts := w.Evaluate(events, next)
+	if len(alerts) != 1 || alerts[0].Count != 1 || alerts[0].Sample != "panic: late" {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+}
+
+func TestRedactAlerts(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	w := NewWatcher([]Rule{mustParseRule(t, "/alice@example\\.com/")}, time.Minute)
+
+	// the rule matches the original message, and only the sample is redacted
+	alerts := w.Evaluate([][]cwTypes.ResultField{event("a", now, "login failed for alice@example.com")}, now)
+	if len(alerts) != 1 {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+	RedactAlerts(alerts, redact.New(redact.DefaultRules()).Redact)
+	if alerts[0].Sample != "login failed for <EMAIL_1>" {
+		t.Errorf("Sample = %q", alerts[0].Sample)
+	}
+}